@@ -0,0 +1,192 @@
+package main
+
+import (
+	"database/sql"
+	"sort"
+
+	"github.com/thoas/go-funk"
+)
+
+// crossPoolRanking is a team at a given rank of its pool, ranked against the teams at the same rank of
+// the other pools. Pools of different sizes are compared the way the tournament normalises them: leaving
+// out the matches against the teams ranked after the size of the smallest pool, or averaging per match.
+type crossPoolRanking struct {
+	PoolIndex int
+	PoolName  string
+	Team      teamRanking
+	Position  int
+	Averaged  bool
+}
+
+func (r crossPoolRanking) PointsPerGame() string {
+	return formatPerGame(r.Team.Points, r.Team.Played)
+}
+
+func (r crossPoolRanking) GoalBalancePerGame() string {
+	return formatPerGame(float64(r.Team.GoalBalance), r.Team.Played)
+}
+
+func (r crossPoolRanking) TeamGoalsPerGame() string {
+	return formatPerGame(float64(r.Team.TeamGoals), r.Team.Played)
+}
+
+// criteria are the points, goal difference and goals scored the team is ranked on.
+func (r crossPoolRanking) criteria() [3]float64 {
+	if r.Averaged {
+		return [3]float64{perGame(r.Team.Points, r.Team.Played), perGame(float64(r.Team.GoalBalance), r.Team.Played), perGame(float64(r.Team.TeamGoals), r.Team.Played)}
+	}
+	return [3]float64{r.Team.Points, float64(r.Team.GoalBalance), float64(r.Team.TeamGoals)}
+}
+
+// rankAcrossPools ranks the teams at the given rank of every pool, pools without that rank having no team.
+func rankAcrossPools(db *sql.DB, t tournament, rank int) []crossPoolRanking {
+	pools := selectTournamentPools(db, t.ID)
+	rankings := make(map[int][]teamRanking)
+	for _, pool := range pools {
+		rankings[pool.Index] = rankPool(db, t.ID, pool.Index)
+	}
+	excluded := make([]int, 0)
+	if t.Normalisation == normalisationWeakest {
+		excluded = weakestTeamIDs(rankings)
+	}
+	slice := make([]crossPoolRanking, 0)
+	for _, pool := range pools {
+		if len(rankings[pool.Index]) < rank {
+			continue
+		}
+		team := rankings[pool.Index][rank-1]
+		if len(excluded) > 0 && !funk.ContainsInt(excluded, team.ID) {
+			for _, normalised := range selectPoolRankingWithout(db, t.ID, pool.Index, excluded) {
+				if normalised.ID == team.ID {
+					team = normalised
+				}
+			}
+			team.Rank = rank
+		}
+		slice = append(slice, crossPoolRanking{PoolIndex: pool.Index, PoolName: pool.Name, Team: team, Averaged: t.Normalisation == normalisationAverage})
+	}
+	sortAcrossPools(slice, t.FairPlayTieBreak)
+	return slice
+}
+
+// sortAcrossPools orders the teams on points, goal difference, goals scored, then fair-play points when
+// the tournament uses them, and numbers their positions.
+func sortAcrossPools(slice []crossPoolRanking, fairPlayTieBreak bool) {
+	sort.SliceStable(slice, func(i, j int) bool {
+		a, b := slice[i].criteria(), slice[j].criteria()
+		for k := range a {
+			if a[k] != b[k] {
+				return a[k] > b[k]
+			}
+		}
+		if fairPlayTieBreak && slice[i].Team.FairPlayPoints != slice[j].Team.FairPlayPoints {
+			return slice[i].Team.FairPlayPoints < slice[j].Team.FairPlayPoints
+		}
+		return slice[i].Team.Name < slice[j].Team.Name
+	})
+	for i := range slice {
+		slice[i].Position = i + 1
+	}
+}
+
+// bestRanks returns the pool ranks the ranking matches take their best teams from, in order.
+func bestRanks(matches []rankingMatch) []int {
+	used := make(map[int]bool)
+	for _, match := range matches {
+		for _, rank := range []sql.NullInt64{match.HomeTeamBestRank, match.VisitorTeamBestRank} {
+			if rank.Valid {
+				used[int(rank.Int64)] = true
+			}
+		}
+	}
+	ranks := make([]int, 0)
+	for rank := range used {
+		ranks = append(ranks, rank)
+	}
+	sort.Ints(ranks)
+	return ranks
+}
+
+// completeBestRankedSlots sends the best teams at a rank to their ranking matches once every pool is over.
+func completeBestRankedSlots(db *sql.DB, tournamentID string) {
+	pools := selectTournamentPools(db, tournamentID)
+	for _, pool := range pools {
+		if countPoolMatchesToBePlayed(db, tournamentID, pool.Index) > 0 {
+			return
+		}
+	}
+	tournament := selectTournament(db, tournamentID)
+	for _, rank := range bestRanks(selectTournamentRankingMatches(db, tournamentID, NullTime{}, NullTime{})) {
+		for _, ranking := range rankAcrossPools(db, tournament, rank) {
+			updateRankingMatchFromBestRank(db, tournamentID, rank, ranking.Position, ranking.Team.ID)
+		}
+	}
+}
+
+// provisionalBestTeams returns the team currently at every position of the best teams at a rank, by rank
+// and position, while the pools are played and once every pool has started.
+func provisionalBestTeams(db *sql.DB, tournamentID string, pools []pool, matches []rankingMatch) map[int]map[int]provisionalTeam {
+	teams := make(map[int]map[int]provisionalTeam)
+	ranks := bestRanks(matches)
+	if len(ranks) == 0 {
+		return teams
+	}
+	over := true
+	for _, pool := range pools {
+		over = over && countPoolMatchesToBePlayed(db, tournamentID, pool.Index) == 0
+		started := false
+		for _, ranking := range selectTournamentPoolRanking(db, tournamentID, pool.Index) {
+			started = started || ranking.Played > 0
+		}
+		if !started {
+			return teams
+		}
+	}
+	if over {
+		return teams
+	}
+	tournament := selectTournament(db, tournamentID)
+	for _, rank := range ranks {
+		teams[rank] = make(map[int]provisionalTeam)
+		for _, ranking := range rankAcrossPools(db, tournament, rank) {
+			teams[rank][ranking.Position] = provisionalTeam{ID: ranking.Team.ID, Name: ranking.Team.Name}
+		}
+	}
+	return teams
+}
+
+// bestRankedViewModel is the ranking of the teams at a rank across pools. NormalisedAfter is the size of
+// the smallest pool when the results against the teams ranked after it are left out, zero otherwise, and
+// Averaged tells the teams are compared on their averages per match.
+type bestRankedViewModel struct {
+	Rank            int
+	NormalisedAfter int
+	Averaged        bool
+	Rankings        []crossPoolRanking
+}
+
+// loadBestRanked ranks across pools the teams at every rank the ranking matches take best teams from.
+func loadBestRanked(db *sql.DB, t tournament) []bestRankedViewModel {
+	poolSizes := make(map[int]int)
+	for _, team := range selectTournamentTeams(db, t.ID) {
+		poolSizes[team.PoolIndex]++
+	}
+	smallestPool, largestPool := 0, 0
+	for _, size := range poolSizes {
+		if smallestPool == 0 || size < smallestPool {
+			smallestPool = size
+		}
+		if size > largestPool {
+			largestPool = size
+		}
+	}
+	views := make([]bestRankedViewModel, 0)
+	for _, rank := range bestRanks(selectTournamentRankingMatches(db, t.ID, NullTime{}, NullTime{})) {
+		view := bestRankedViewModel{Rank: rank, Averaged: t.Normalisation == normalisationAverage, Rankings: rankAcrossPools(db, t, rank)}
+		if t.Normalisation == normalisationWeakest && smallestPool != largestPool && rank <= smallestPool {
+			view.NormalisedAfter = smallestPool
+		}
+		views = append(views, view)
+	}
+	return views
+}
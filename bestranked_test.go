@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestSortAcrossPools(t *testing.T) {
+	slice := []crossPoolRanking{
+		{PoolName: "A", Team: teamRanking{Name: "Tigers", Points: 3, GoalBalance: 0, TeamGoals: 2}},
+		{PoolName: "B", Team: teamRanking{Name: "Wolves", Points: 4, GoalBalance: -1, TeamGoals: 1}},
+		{PoolName: "C", Team: teamRanking{Name: "Seals", Points: 3, GoalBalance: 0, TeamGoals: 3, FairPlayPoints: 2}},
+		{PoolName: "D", Team: teamRanking{Name: "Owls", Points: 3, GoalBalance: 0, TeamGoals: 3}},
+	}
+	sortAcrossPools(slice, false)
+	expected := []string{"Wolves", "Owls", "Seals", "Tigers"}
+	for i, name := range expected {
+		if slice[i].Team.Name != name || slice[i].Position != i+1 {
+			t.Errorf("Expected %s at position %d, got %+v.", name, i+1, slice[i])
+		}
+	}
+	slice[1].Team.FairPlayPoints, slice[2].Team.FairPlayPoints = 2, 0
+	sortAcrossPools(slice, true)
+	if slice[1].Team.Name != "Seals" {
+		t.Errorf("Expected the fair-play points to break the tie, got %+v.", slice)
+	}
+}
+
+func TestSortAcrossPoolsAveraged(t *testing.T) {
+	slice := []crossPoolRanking{
+		{PoolName: "A", Averaged: true, Team: teamRanking{Name: "Tigers", Points: 9, Played: 4, GoalBalance: 4}},
+		{PoolName: "B", Averaged: true, Team: teamRanking{Name: "Wolves", Points: 7, Played: 3, GoalBalance: 1}},
+	}
+	sortAcrossPools(slice, false)
+	if slice[0].Team.Name != "Wolves" || slice[0].PointsPerGame() != "2.33" {
+		t.Errorf("Expected Wolves first on points per match, got %+v.", slice)
+	}
+}
+
+func TestBestRanks(t *testing.T) {
+	matches := []rankingMatch{
+		{Key: "QF1", HomeTeamPoolIndex: nullInt(1), HomeTeamPoolRank: nullInt(1), VisitorTeamBestRank: nullInt(3), VisitorTeamBestPosition: nullInt(2)},
+		{Key: "QF2", HomeTeamBestRank: nullInt(3), HomeTeamBestPosition: nullInt(1), VisitorTeamBestRank: nullInt(2), VisitorTeamBestPosition: nullInt(1)},
+	}
+	if ranks := bestRanks(matches); len(ranks) != 2 || ranks[0] != 2 || ranks[1] != 3 {
+		t.Errorf("Expected the best 2nd and 3rd-placed teams, got %v.", ranks)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"sort"
+)
+
+// bracketNode is a ranking match with the matches whose winners it opposes. Teams coming from a pool
+// or from the loser of a match have no node, their placeholder name is shown on the match.
+type bracketNode struct {
+	Match    rankingMatch
+	Children []bracketNode
+}
+
+// bracketTree is a knockout bracket ending with a match whose winner plays no other match: the final,
+// or the last match of a placement sub-bracket.
+type bracketTree struct {
+	Root bracketNode
+	// WinnerRank and LooserRank are the final ranks decided by the last match, zero when there is none.
+	WinnerRank int
+	LooserRank int
+}
+
+// rankingBrackets builds the brackets of the ranking matches by following the winners of every match.
+// The losers of a match start a placement sub-bracket, so every match belongs to exactly one bracket.
+// Brackets are sorted by the final rank they decide, the final first.
+func rankingBrackets(matches []rankingMatch) []bracketTree {
+	byKey := make(map[string]rankingMatch)
+	winnerPlays := make(map[string]bool)
+	for _, match := range matches {
+		byKey[match.Key] = match
+		if match.HomeTeamSourceRankingMatch.Valid && match.HomeTeamSourceRankingMatchWinner.Bool {
+			winnerPlays[match.HomeTeamSourceRankingMatch.String] = true
+		}
+		if match.VisitorTeamSourceRankingMatch.Valid && match.VisitorTeamSourceRankingMatchWinner.Bool {
+			winnerPlays[match.VisitorTeamSourceRankingMatch.String] = true
+		}
+	}
+	trees := make([]bracketTree, 0)
+	for _, match := range matches {
+		if winnerPlays[match.Key] {
+			continue
+		}
+		trees = append(trees, bracketTree{
+			Root:       bracketNodeOf(match, byKey),
+			WinnerRank: int(match.WinnerFinalRank.Int64),
+			LooserRank: int(match.LooserFinalRank.Int64),
+		})
+	}
+	sort.SliceStable(trees, func(i, j int) bool {
+		a, b := trees[i], trees[j]
+		if (a.WinnerRank == 0) != (b.WinnerRank == 0) {
+			return a.WinnerRank != 0
+		}
+		if a.WinnerRank != b.WinnerRank {
+			return a.WinnerRank < b.WinnerRank
+		}
+		return a.Root.Match.ScheduledAt.Before(b.Root.Match.ScheduledAt)
+	})
+	return trees
+}
+
+func bracketNodeOf(match rankingMatch, byKey map[string]rankingMatch) bracketNode {
+	node := bracketNode{Match: match, Children: make([]bracketNode, 0)}
+	if match.HomeTeamSourceRankingMatch.Valid && match.HomeTeamSourceRankingMatchWinner.Bool {
+		if source, ok := byKey[match.HomeTeamSourceRankingMatch.String]; ok {
+			node.Children = append(node.Children, bracketNodeOf(source, byKey))
+		}
+	}
+	if match.VisitorTeamSourceRankingMatch.Valid && match.VisitorTeamSourceRankingMatchWinner.Bool {
+		if source, ok := byKey[match.VisitorTeamSourceRankingMatch.String]; ok {
+			node.Children = append(node.Children, bracketNodeOf(source, byKey))
+		}
+	}
+	return node
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func bracketMatch(key string, scheduledAt string, homeSource string, visitorSource string, winners bool, winnerRank int64, looserRank int64) rankingMatch {
+	match := rankingMatch{Key: key, ScheduledAt: parseTime(scheduledAt)}
+	if homeSource != "" {
+		match.HomeTeamSourceRankingMatch = sql.NullString{String: homeSource, Valid: true}
+		match.HomeTeamSourceRankingMatchWinner = sql.NullBool{Bool: winners, Valid: true}
+		match.VisitorTeamSourceRankingMatch = sql.NullString{String: visitorSource, Valid: true}
+		match.VisitorTeamSourceRankingMatchWinner = sql.NullBool{Bool: winners, Valid: true}
+	}
+	if winnerRank != 0 {
+		match.WinnerFinalRank = nullInt(winnerRank)
+		match.LooserFinalRank = nullInt(looserRank)
+	}
+	return match
+}
+
+func TestRankingBrackets(t *testing.T) {
+	matches := []rankingMatch{
+		bracketMatch("SF1", "10:00", "", "", false, 0, 0),
+		bracketMatch("SF2", "10:00", "", "", false, 0, 0),
+		bracketMatch("P3", "11:00", "SF1", "SF2", false, 3, 4),
+		bracketMatch("F", "11:30", "SF1", "SF2", true, 1, 2),
+	}
+	brackets := rankingBrackets(matches)
+	if len(brackets) != 2 {
+		t.Fatalf("Expected the final and third place brackets, got %d brackets.", len(brackets))
+	}
+	final := brackets[0]
+	if final.Root.Match.Key != "F" || final.WinnerRank != 1 || final.LooserRank != 2 {
+		t.Errorf("Expected the final first, got %+v.", final.Root.Match)
+	}
+	if len(final.Root.Children) != 2 || final.Root.Children[0].Match.Key != "SF1" || final.Root.Children[1].Match.Key != "SF2" {
+		t.Errorf("Expected the semi-finals to feed the final, got %+v.", final.Root.Children)
+	}
+	if third := brackets[1]; third.Root.Match.Key != "P3" || len(third.Root.Children) != 0 {
+		t.Errorf("Expected the third place match without children, got %+v.", third.Root)
+	}
+}
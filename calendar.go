@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// calendarEvent is a match in an iCalendar feed. Its UID only depends on the tournament and on the match,
+// so that calendar applications replace the event when the match is moved or its result entered.
+type calendarEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time
+}
+
+// calendarEvents turns matches into events, teamID narrowing the description to the opponent of a team
+// when it is not zero. It fails when the tournament has an unknown time zone.
+func calendarEvents(t tournament, matches []displayMatch, teamID int, locale string) ([]calendarEvent, error) {
+	duration := time.Duration(t.GameDurationMinutes) * time.Minute
+	events := make([]calendarEvent, 0)
+	for _, match := range matches {
+		start, err := t.MatchTime(match.Start)
+		if err != nil {
+			return nil, err
+		}
+		lines := []string{match.Label}
+		if teamID != 0 && teamID == match.HomeTeamID {
+			lines = append(lines, translate(locale, "opponent", match.VisitorTeamName))
+		} else if teamID != 0 && teamID == match.VisitorTeamID {
+			lines = append(lines, translate(locale, "opponent", match.HomeTeamName))
+		}
+		if match.Played {
+			lines = append(lines, translate(locale, "result", match.Score))
+		}
+		events = append(events, calendarEvent{
+			UID:         fmt.Sprintf("%s-%s@vaff-tournament", t.ID, match.Ref),
+			Summary:     fmt.Sprintf("%s: %s - %s", t.Name, match.HomeTeamName, match.VisitorTeamName),
+			Description: strings.Join(lines, "\n"),
+			Location:    translate(locale, "pitch", match.PitchName),
+			Start:       start,
+			End:         start.Add(duration),
+		})
+	}
+	return events, nil
+}
+
+// writeCalendar writes the events as an iCalendar (RFC 5545) document, times being written in UTC.
+func writeCalendar(w io.Writer, name string, events []calendarEvent, now time.Time) error {
+	b := bufio.NewWriter(w)
+	line := func(property string, value string) {
+		writeCalendarLine(b, property+":"+value)
+	}
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//VAFF//Tournament//FR")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	line("X-WR-CALNAME", escapeCalendarText(name))
+	for _, event := range events {
+		line("BEGIN", "VEVENT")
+		line("UID", event.UID)
+		line("DTSTAMP", now.UTC().Format("20060102T150405Z"))
+		line("DTSTART", event.Start.UTC().Format("20060102T150405Z"))
+		line("DTEND", event.End.UTC().Format("20060102T150405Z"))
+		line("SUMMARY", escapeCalendarText(event.Summary))
+		line("DESCRIPTION", escapeCalendarText(event.Description))
+		line("LOCATION", escapeCalendarText(event.Location))
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return b.Flush()
+}
+
+func escapeCalendarText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(text)
+}
+
+// writeCalendarLine folds the content line into lines of at most 75 octets, without splitting UTF-8 characters.
+func writeCalendarLine(w *bufio.Writer, content string) {
+	limit := 75
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && content[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.WriteString(content[:cut] + "\r\n ")
+		content = content[cut:]
+		limit = 74
+	}
+	w.WriteString(content + "\r\n")
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTournamentMatchTime(t *testing.T) {
+	tournament := tournament{Date: "2024-06-15", TimeZone: "Europe/Paris"}
+	start, err := tournament.MatchTime(parseScheduledAt("2024-06-16 09:30"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := time.Date(2024, 6, 16, 7, 30, 0, 0, time.UTC); !start.Equal(expected) {
+		t.Errorf("Expected %v, got %v.", expected, start.UTC())
+	}
+	tournament.TimeZone = "Mars/Olympus"
+	if _, err := tournament.MatchTime(parseScheduledAt("2024-06-16 09:30")); err == nil {
+		t.Errorf("Expected an error with an unknown time zone.")
+	}
+}
+
+func tournament2024(date string) tournament {
+	return tournament{ID: "U11", Name: "U11", Date: date, TimeZone: "Europe/Paris", GameDurationMinutes: 20}
+}
+
+func TestCalendarEvents(t *testing.T) {
+	matches := []displayMatch{
+		{Ref: "pool-1-3", Label: "Poule A", Start: parseScheduledAt("2024-06-15 10:00"), PitchName: "1", HomeTeamID: 1, HomeTeamName: "Lions", VisitorTeamID: 2, VisitorTeamName: "Tigres", Score: "2–1", Played: true},
+	}
+	events, err := calendarEvents(tournament2024("2024-06-15"), matches, 2, "fr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := events[0]
+	if event.UID != "U11-pool-1-3@vaff-tournament" {
+		t.Errorf("Expected a UID made of the tournament and of the match, got %q.", event.UID)
+	}
+	if event.Description != "Poule A\nAdversaire : Lions\nRésultat : 2–1" {
+		t.Errorf("Expected the opponent and the result in the description, got %q.", event.Description)
+	}
+	if event.Location != "Terrain 1" || event.End.Sub(event.Start) != 20*time.Minute {
+		t.Errorf("Expected a 20 minutes event on pitch 1, got %+v.", event)
+	}
+}
+
+func TestWriteCalendar(t *testing.T) {
+	start := time.Date(2024, 6, 15, 8, 0, 0, 0, time.UTC)
+	events := []calendarEvent{{
+		UID:         "U11-ranking-F@vaff-tournament",
+		Summary:     "U11: Lions, Tigres",
+		Description: strings.Repeat("é", 50),
+		Start:       start,
+		End:         start.Add(20 * time.Minute),
+	}}
+	var sb strings.Builder
+	if err := writeCalendar(&sb, "U11", events, start); err != nil {
+		t.Fatal(err)
+	}
+	ics := sb.String()
+	for _, expected := range []string{"BEGIN:VCALENDAR\r\n", "DTSTART:20240615T080000Z\r\n", "SUMMARY:U11: Lions\\, Tigres\r\n", "END:VCALENDAR\r\n"} {
+		if !strings.Contains(ics, expected) {
+			t.Errorf("Expected %q in the calendar.", expected)
+		}
+	}
+	for _, line := range strings.Split(ics, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("Expected lines of at most 75 octets, got %q.", line)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `usage:
+  tournament                            start the web server
+  tournament validate <tournamentID>    print the schedule validation report`
+
+// runCommand runs the command line tools and returns the process exit code.
+func runCommand(db *sql.DB, args []string) int {
+	switch {
+	case args[0] == "validate" && len(args) == 2:
+		return validateCommand(db, args[1], os.Stdout)
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+}
+
+func validateCommand(db *sql.DB, tournamentID string, out io.Writer) int {
+	if !tournamentExists(db, tournamentID) {
+		fmt.Fprintf(out, "Unknown tournament %s\n", tournamentID)
+		return 2
+	}
+	tournament := selectTournament(db, tournamentID)
+	issues := validateSchedule(loadScheduledMatches(db, tournamentID), tournamentScheduleSettings(tournament))
+	if len(issues) == 0 {
+		fmt.Fprintf(out, "%s: no schedule issue\n", tournamentID)
+		return 0
+	}
+	fmt.Fprintf(out, "%s: %d schedule issue(s)\n", tournamentID, len(issues))
+	for _, issue := range issues {
+		fmt.Fprintf(out, "[%s] %s\n", issue.Kind, issue.Message)
+	}
+	return 1
+}
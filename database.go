@@ -3,6 +3,7 @@ package main
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -11,6 +12,10 @@ import (
 
 const timeFormat = "15:04"
 
+// scheduledAtFormat is the layout of the scheduled_at column of the matches, a date and a time of day in the
+// time zone of the tournament.
+const scheduledAtFormat = "2006-01-02 15:04"
+
 func initDB() *sql.DB {
 	db, err := sql.Open("sqlite3", "tournament.db?cache=shared&mode=rwc")
 	if err != nil {
@@ -99,6 +104,256 @@ func migrateDB(db *sql.DB) {
 					);
 				`},
 			},
+			&migrate.Migration{
+				Id: "2",
+				Up: []string{
+					`
+					ALTER TABLE tournament ADD COLUMN status TEXT NOT NULL DEFAULT 'published';
+					ALTER TABLE tournament ADD COLUMN deleted_at TEXT;
+				`},
+			},
+			&migrate.Migration{
+				Id: "3",
+				Up: []string{
+					`
+					ALTER TABLE tournament ADD COLUMN game_duration_minutes INTEGER NOT NULL DEFAULT 20;
+					ALTER TABLE tournament ADD COLUMN min_rest_minutes INTEGER NOT NULL DEFAULT 0;
+					ALTER TABLE tournament ADD COLUMN playing_windows TEXT NOT NULL DEFAULT '';
+				`},
+			},
+			&migrate.Migration{
+				Id: "4",
+				Up: []string{
+					`
+					ALTER TABLE tournament ADD COLUMN locale TEXT NOT NULL DEFAULT 'fr';
+				`},
+			},
+			&migrate.Migration{
+				Id: "5",
+				Up: []string{
+					`
+					ALTER TABLE ranking_match ADD COLUMN home_team_extra_time_goals INTEGER;
+					ALTER TABLE ranking_match ADD COLUMN visitor_team_extra_time_goals INTEGER;
+					ALTER TABLE ranking_match ADD COLUMN home_team_penalty_goals INTEGER;
+					ALTER TABLE ranking_match ADD COLUMN visitor_team_penalty_goals INTEGER;
+				`},
+			},
+			&migrate.Migration{
+				Id: "6",
+				Up: []string{
+					`
+					ALTER TABLE pool_match ADD COLUMN result_status TEXT NOT NULL DEFAULT 'played';
+					ALTER TABLE ranking_match ADD COLUMN result_status TEXT NOT NULL DEFAULT 'played';
+					ALTER TABLE team ADD COLUMN withdrawn BOOLEAN NOT NULL DEFAULT 0;
+					ALTER TABLE tournament ADD COLUMN forfeit_goals INTEGER NOT NULL DEFAULT 3;
+					ALTER TABLE tournament ADD COLUMN forfeit_penalty_points REAL NOT NULL DEFAULT 0;
+					ALTER TABLE tournament ADD COLUMN forfeit_goals_counted BOOLEAN NOT NULL DEFAULT 0;
+				`},
+			},
+			&migrate.Migration{
+				Id: "7",
+				Up: []string{
+					`
+					ALTER TABLE tournament ADD COLUMN scoring_model TEXT NOT NULL DEFAULT 'goals';
+
+					CREATE TABLE match_set (
+						tournament_id TEXT NOT NULL REFERENCES tournament(id),
+						match_ref TEXT NOT NULL,
+						set_index INTEGER NOT NULL,
+						home_points INTEGER NOT NULL,
+						visitor_points INTEGER NOT NULL,
+						PRIMARY KEY(tournament_id, match_ref, set_index)
+					);
+				`},
+			},
+			&migrate.Migration{
+				Id: "8",
+				Up: []string{
+					`
+					CREATE TABLE player (
+						id INTEGER PRIMARY KEY AUTOINCREMENT,
+						tournament_id TEXT NOT NULL REFERENCES tournament(id),
+						team_id INTEGER NOT NULL,
+						name TEXT NOT NULL,
+						number INTEGER,
+						licence_id TEXT NOT NULL DEFAULT ''
+					);
+
+					CREATE TABLE goal (
+						tournament_id TEXT NOT NULL REFERENCES tournament(id),
+						match_ref TEXT NOT NULL,
+						goal_index INTEGER NOT NULL,
+						team_id INTEGER NOT NULL,
+						player_id INTEGER REFERENCES player(id),
+						minute INTEGER,
+						PRIMARY KEY(tournament_id, match_ref, goal_index)
+					);
+				`},
+			},
+			&migrate.Migration{
+				Id: "9",
+				Up: []string{
+					`
+					ALTER TABLE tournament ADD COLUMN yellow_card_points REAL NOT NULL DEFAULT 1;
+					ALTER TABLE tournament ADD COLUMN red_card_points REAL NOT NULL DEFAULT 3;
+					ALTER TABLE tournament ADD COLUMN yellow_cards_per_suspension INTEGER NOT NULL DEFAULT 2;
+					ALTER TABLE tournament ADD COLUMN fair_play_tie_break BOOLEAN NOT NULL DEFAULT 0;
+
+					CREATE TABLE card (
+						id INTEGER PRIMARY KEY AUTOINCREMENT,
+						tournament_id TEXT NOT NULL REFERENCES tournament(id),
+						match_ref TEXT NOT NULL,
+						team_id INTEGER NOT NULL,
+						player_id INTEGER REFERENCES player(id),
+						card_type TEXT NOT NULL,
+						minute INTEGER
+					);
+				`},
+			},
+			&migrate.Migration{
+				Id: "10",
+				Up: []string{
+					`
+					CREATE TABLE referee (
+						id INTEGER PRIMARY KEY AUTOINCREMENT,
+						tournament_id TEXT NOT NULL REFERENCES tournament(id),
+						name TEXT NOT NULL
+					);
+
+					CREATE TABLE match_referee (
+						tournament_id TEXT NOT NULL REFERENCES tournament(id),
+						match_ref TEXT NOT NULL,
+						referee_id INTEGER REFERENCES referee(id),
+						referee_team_id INTEGER,
+						PRIMARY KEY(tournament_id, match_ref)
+					);
+				`},
+			},
+			&migrate.Migration{
+				Id: "11",
+				Up: []string{
+					`
+					CREATE TABLE display (
+						id INTEGER PRIMARY KEY AUTOINCREMENT,
+						name TEXT NOT NULL,
+						locale TEXT NOT NULL DEFAULT 'fr',
+						slide_seconds INTEGER NOT NULL DEFAULT 15,
+						upcoming_minutes INTEGER NOT NULL DEFAULT 30,
+						latest_results INTEGER NOT NULL DEFAULT 8
+					);
+
+					CREATE TABLE display_tournament (
+						display_id INTEGER NOT NULL REFERENCES display(id),
+						tournament_id TEXT NOT NULL REFERENCES tournament(id),
+						PRIMARY KEY(display_id, tournament_id)
+					);
+
+					CREATE TABLE display_slide (
+						id INTEGER PRIMARY KEY AUTOINCREMENT,
+						display_id INTEGER NOT NULL REFERENCES display(id),
+						kind TEXT NOT NULL,
+						image_url TEXT NOT NULL DEFAULT '',
+						seconds INTEGER
+					);
+				`},
+			},
+			&migrate.Migration{
+				Id: "12",
+				Up: []string{
+					`
+					ALTER TABLE tournament ADD COLUMN date TEXT NOT NULL DEFAULT '';
+					ALTER TABLE tournament ADD COLUMN time_zone TEXT NOT NULL DEFAULT 'Europe/Paris';
+				`},
+			},
+			&migrate.Migration{
+				Id: "13",
+				Up: []string{
+					`
+					CREATE TABLE webhook (
+						id INTEGER PRIMARY KEY AUTOINCREMENT,
+						tournament_id TEXT NOT NULL REFERENCES tournament(id),
+						url TEXT NOT NULL,
+						secret TEXT NOT NULL,
+						events TEXT NOT NULL
+					);
+
+					CREATE TABLE webhook_delivery (
+						id INTEGER PRIMARY KEY AUTOINCREMENT,
+						webhook_id INTEGER NOT NULL REFERENCES webhook(id),
+						event TEXT NOT NULL,
+						payload TEXT NOT NULL,
+						status TEXT NOT NULL DEFAULT 'pending',
+						attempts INTEGER NOT NULL DEFAULT 0,
+						next_attempt_at TEXT NOT NULL,
+						last_status_code INTEGER,
+						last_error TEXT NOT NULL DEFAULT '',
+						created_at TEXT NOT NULL
+					);
+
+					CREATE INDEX webhook_delivery_due ON webhook_delivery(status, next_attempt_at);
+				`},
+			},
+			&migrate.Migration{
+				Id: "14",
+				Up: []string{
+					`
+					CREATE TABLE score_submission (
+						id TEXT PRIMARY KEY,
+						tournament_id TEXT NOT NULL REFERENCES tournament(id),
+						match_ref TEXT NOT NULL,
+						received_at TEXT NOT NULL
+					);
+				`},
+			},
+			&migrate.Migration{
+				Id: "15",
+				Up: []string{
+					`
+					CREATE TABLE live_match (
+						tournament_id TEXT NOT NULL REFERENCES tournament(id),
+						match_ref TEXT NOT NULL,
+						status TEXT NOT NULL,
+						elapsed_seconds INTEGER NOT NULL DEFAULT 0,
+						clock_started_at TEXT,
+						PRIMARY KEY (tournament_id, match_ref)
+					);
+				`},
+			},
+			&migrate.Migration{
+				Id: "16",
+				Up: []string{
+					`
+					ALTER TABLE ranking_match ADD COLUMN home_team_best_rank INTEGER;
+					ALTER TABLE ranking_match ADD COLUMN home_team_best_position INTEGER;
+					ALTER TABLE ranking_match ADD COLUMN visitor_team_best_rank INTEGER;
+					ALTER TABLE ranking_match ADD COLUMN visitor_team_best_position INTEGER;
+				`},
+			},
+			&migrate.Migration{
+				Id: "17",
+				Up: []string{
+					`
+					ALTER TABLE tournament ADD COLUMN normalisation TEXT NOT NULL DEFAULT 'none';
+				`},
+			},
+			&migrate.Migration{
+				Id: "18",
+				Up: []string{
+					`
+					ALTER TABLE tournament ADD COLUMN eliminated_ranking TEXT NOT NULL DEFAULT 'compared';
+				`},
+			},
+			&migrate.Migration{
+				Id: "19",
+				Up: []string{
+					`
+					UPDATE tournament SET date = date('now') WHERE date = '';
+					UPDATE pool_match SET scheduled_at = (SELECT date FROM tournament WHERE tournament.id = pool_match.tournament_id) || ' ' || scheduled_at
+					WHERE length(scheduled_at) = 5;
+					UPDATE ranking_match SET scheduled_at = (SELECT date FROM tournament WHERE tournament.id = ranking_match.tournament_id) || ' ' || scheduled_at
+					WHERE length(scheduled_at) = 5;
+				`},
+			},
 		},
 	}
 	n, err := migrate.Exec(db, "sqlite3", migrations, migrate.Up)
@@ -110,7 +365,7 @@ func migrateDB(db *sql.DB) {
 
 func selectAllTournamentPoolMatches(db *sql.DB, tournamentID string) []poolMatch {
 	sql := `
-		SELECT match.id, match.pool_index, match.scheduled_at, home_team.name, visitor_team.name, match.home_team_goals, match.visitor_team_goals, pitch.name AS pitch_name
+		SELECT match.id, match.pool_index, match.scheduled_at, match.home_team_id, home_team.name, match.visitor_team_id, visitor_team.name, match.home_team_goals, match.visitor_team_goals, match.result_status, match.pitch_id, pitch.name AS pitch_name
 		FROM pool_match match 
 		JOIN team home_team ON match.home_team_id = home_team.id AND home_team.tournament_id = $1
 		JOIN team visitor_team ON match.visitor_team_id = visitor_team.id AND visitor_team.tournament_id = $1
@@ -124,7 +379,7 @@ func selectAllTournamentPoolMatches(db *sql.DB, tournamentID string) []poolMatch
 func selectTournamentPoolMatches(db *sql.DB, tournamentID string, poolIndex int, from NullTime, to NullTime) []poolMatch {
 	timeFilter := timeFilter(from, to)
 	sql := `
-			SELECT match.id, match.pool_index, match.scheduled_at, home_team.name, visitor_team.name, match.home_team_goals, match.visitor_team_goals, pitch.name AS pitch_name
+			SELECT match.id, match.pool_index, match.scheduled_at, match.home_team_id, home_team.name, match.visitor_team_id, visitor_team.name, match.home_team_goals, match.visitor_team_goals, match.result_status, match.pitch_id, pitch.name AS pitch_name
 			FROM pool_match match 
 			JOIN team home_team ON match.home_team_id = home_team.id AND home_team.tournament_id = $1
 			JOIN team visitor_team ON match.visitor_team_id = visitor_team.id AND visitor_team.tournament_id = $1
@@ -139,10 +394,10 @@ func selectTournamentPoolMatches(db *sql.DB, tournamentID string, poolIndex int,
 func timeFilter(from NullTime, to NullTime) string {
 	timeFilter := ""
 	if from.Valid {
-		timeFilter = "AND scheduled_at >= '" + formatTime(from.Time) + "' "
+		timeFilter = "AND strftime('%H:%M', scheduled_at) >= '" + formatTime(from.Time) + "' "
 	}
 	if to.Valid {
-		timeFilter = "AND scheduled_at < '" + formatTime(to.Time) + "' "
+		timeFilter = "AND strftime('%H:%M', scheduled_at) < '" + formatTime(to.Time) + "' "
 	}
 	return timeFilter
 }
@@ -156,11 +411,11 @@ func fetchPoolMatches(rows *sql.Rows, err error) []poolMatch {
 	for rows.Next() {
 		match := poolMatch{}
 		var scheduledAtStr string
-		err2 := rows.Scan(&match.ID, &match.PoolIndex, &scheduledAtStr, &match.HomeTeamName, &match.VisitorTeamName, &match.HomeTeamGoals, &match.VisitorTeamGoals, &match.PitchName)
+		err2 := rows.Scan(&match.ID, &match.PoolIndex, &scheduledAtStr, &match.HomeTeamID, &match.HomeTeamName, &match.VisitorTeamID, &match.VisitorTeamName, &match.HomeTeamGoals, &match.VisitorTeamGoals, &match.ResultStatus, &match.PitchID, &match.PitchName)
 		if err2 != nil {
 			panic(err2)
 		}
-		match.ScheduledAt = parseTime(scheduledAtStr)
+		match.ScheduledAt = parseScheduledAt(scheduledAtStr)
 		slice = append(slice, match)
 	}
 	return slice
@@ -172,8 +427,10 @@ func selectTournamentRankingMatches(db *sql.DB, tournamentID string, from NullTi
 		SELECT match.key, scheduled_at,
 			home_team.name,    home_team_pool_index,    home_team_pool_rank,    home_team_source_ranking_match,    home_team_source_ranking_match_winner,    home_team_goals,    home_team_id,
 			visitor_team.name, visitor_team_pool_index, visitor_team_pool_rank, visitor_team_source_ranking_match, visitor_team_source_ranking_match_winner, visitor_team_goals, visitor_team_id,
-			winner_team_id, looser_team_id,
-			pitch.name AS pitch_name
+			home_team_extra_time_goals, visitor_team_extra_time_goals, home_team_penalty_goals, visitor_team_penalty_goals,
+			match.result_status, winner_team_id, looser_team_id, winner_final_rank, looser_final_rank,
+			match.pitch_id, pitch.name AS pitch_name,
+			home_team_best_rank, home_team_best_position, visitor_team_best_rank, visitor_team_best_position
 		FROM ranking_match match 
 		JOIN pitch ON match.pitch_id = pitch.id AND pitch.tournament_id = $1
 		LEFT JOIN team home_team ON match.home_team_id = home_team.id AND home_team.tournament_id = $1
@@ -198,18 +455,29 @@ func selectTournamentRankingMatches(db *sql.DB, tournamentID string, from NullTi
 			&match.VisitorTeamName,
 			&match.VisitorTeamPoolIndex, &match.VisitorTeamPoolRank, &match.VisitorTeamSourceRankingMatch, &match.VisitorTeamSourceRankingMatchWinner,
 			&match.VisitorTeamGoals, &match.VisitorTeamID,
-			&match.WinnerTeamID, &match.LooserTeamID,
-			&match.PitchName)
+			&match.HomeTeamExtraTimeGoals, &match.VisitorTeamExtraTimeGoals, &match.HomeTeamPenaltyGoals, &match.VisitorTeamPenaltyGoals,
+			&match.ResultStatus, &match.WinnerTeamID, &match.LooserTeamID, &match.WinnerFinalRank, &match.LooserFinalRank,
+			&match.PitchID, &match.PitchName,
+			&match.HomeTeamBestRank, &match.HomeTeamBestPosition, &match.VisitorTeamBestRank, &match.VisitorTeamBestPosition)
 		if err2 != nil {
 			panic(err2)
 		}
-		match.ScheduledAt = parseTime(scheduledAtStr)
+		match.ScheduledAt = parseScheduledAt(scheduledAtStr)
 		slice = append(slice, match)
 	}
 	return slice
 }
 
 func selectTournamentPoolRanking(db *sql.DB, tournamentID string, poolIndex int) []teamRanking {
+	return selectPoolRankingWithout(db, tournamentID, poolIndex, nil)
+}
+
+// selectPoolRankingWithout ranks the pool leaving out the matches against the excluded teams.
+func selectPoolRankingWithout(db *sql.DB, tournamentID string, poolIndex int, excludedTeamIDs []int) []teamRanking {
+	excluded := ","
+	for _, teamID := range excludedTeamIDs {
+		excluded += fmt.Sprintf("%d,", teamID)
+	}
 	sql := `
 		WITH finished_games AS (
 			SELECT *
@@ -218,32 +486,45 @@ func selectTournamentPoolRanking(db *sql.DB, tournamentID string, poolIndex int)
 			  AND pool_index=$2
 			  AND home_team_goals IS NOT NULL 
 			  AND visitor_team_goals IS NOT NULL
+			  AND result_status != 'abandoned'
+			  AND NOT EXISTS (` + liveMatchInProgress + `)
+			  AND instr($3, ',' || home_team_id || ',') = 0
+			  AND instr($3, ',' || visitor_team_id || ',') = 0
 		), team_matches AS (
-			SELECT team.id AS id, team.name AS name, finished_games.id, home_team_goals AS team_goals, visitor_team_goals AS opponent_goals
+			SELECT team.id AS id, team.name AS name, finished_games.id, home_team_goals AS team_goals, visitor_team_goals AS opponent_goals,
+				result_status IN ('home_forfeit', 'double_forfeit') AS forfeit, result_status = 'visitor_forfeit' AS opponent_forfeit, result_status
 			FROM team 
 			JOIN finished_games ON finished_games.home_team_id = team.id AND finished_games.tournament_id = team.tournament_id
 			UNION
-			SELECT team.id AS id, team.name AS name, finished_games.id, visitor_team_goals AS team_goals, home_team_goals AS opponent_goals
+			SELECT team.id AS id, team.name AS name, finished_games.id, visitor_team_goals AS team_goals, home_team_goals AS opponent_goals,
+				result_status IN ('visitor_forfeit', 'double_forfeit') AS forfeit, result_status = 'home_forfeit' AS opponent_forfeit, result_status
 			FROM team 
 			JOIN finished_games ON finished_games.visitor_team_id = team.id AND finished_games.tournament_id = team.tournament_id
 		), team_result AS (
 			SELECT 
-				id,
+				team_matches.id,
 				CASE 
+					WHEN opponent_forfeit THEN 1
+					WHEN forfeit THEN 0
 					WHEN team_goals > opponent_goals THEN 1
 					ELSE 0
 				END AS win, 
 				CASE 
+					WHEN forfeit OR opponent_forfeit THEN 0
 					WHEN team_goals = opponent_goals THEN 1
 					ELSE 0
 				END AS draw, 
 				CASE 
+					WHEN forfeit THEN 1
+					WHEN opponent_forfeit THEN 0
 					WHEN team_goals < opponent_goals THEN 1
 					ELSE 0
 				END AS defeat,
-				team_goals,
-				opponent_goals
+				forfeit,
+				CASE WHEN result_status = 'played' OR forfeit_goals_counted THEN team_goals ELSE 0 END AS team_goals,
+				CASE WHEN result_status = 'played' OR forfeit_goals_counted THEN opponent_goals ELSE 0 END AS opponent_goals
 			FROM team_matches
+			JOIN tournament ON tournament.id = $1
 		), team_summary AS (
 			SELECT team_result.id, COUNT(*) AS played, SUM(win) AS win_count, SUM(draw) AS draw_count , SUM(defeat) AS defeat_count, SUM(team_goals) AS team_goals, SUM(opponent_goals) AS opponent_goals, (SUM(team_goals) - SUM(opponent_goals)) AS goal_balance,
 				(SUM(win)*points_per_win)
@@ -252,11 +533,19 @@ func selectTournamentPoolRanking(db *sql.DB, tournamentID string, poolIndex int)
 				+
 				(SUM(defeat)*points_per_defeat)
 				+
-				(SUM(team_goals)*points_per_goal) AS points
+				(SUM(team_goals)*points_per_goal)
+				-
+				(SUM(forfeit)*forfeit_penalty_points) AS points
 			FROM team_result
 			JOIN tournament 
 			WHERE tournament.id = $1
 			GROUP BY team_result.id
+		), fair_play AS (
+			SELECT team_id, SUM(CASE card_type WHEN 'red' THEN red_card_points ELSE yellow_card_points END) AS fair_play_points
+			FROM card
+			JOIN tournament ON tournament.id = card.tournament_id
+			WHERE card.tournament_id = $1 AND card.match_ref LIKE 'pool-' || $2 || '-%'
+			GROUP BY team_id
 		)
 		SELECT 
 		  team.id,
@@ -269,15 +558,19 @@ func selectTournamentPoolRanking(db *sql.DB, tournamentID string, poolIndex int)
 			COALESCE(opponent_goals, 0),
 			COALESCE(goal_balance, 0),
 			COALESCE(points, 0),
-			RANK() OVER ( ORDER BY points DESC, goal_balance DESC, name ) rank,
+			COALESCE(fair_play_points, 0),
+			RANK() OVER ( ORDER BY points DESC, goal_balance DESC,
+				CASE WHEN (SELECT fair_play_tie_break FROM tournament WHERE id = $1) THEN COALESCE(fair_play_points, 0) ELSE 0 END,
+				name ) rank,
 			RANK() OVER (ORDER BY team_goals DESC, goal_balance DESC) AS attack_rank,
 			RANK() OVER (ORDER BY opponent_goals ASC, goal_balance DESC) AS defense_rank
 		FROM team 
 		LEFT JOIN team_summary ON team.id = team_summary.id
+		LEFT JOIN fair_play ON team.id = fair_play.team_id
 		WHERE team.tournament_id = $1 AND team.pool_index = $2
 		ORDER BY rank	
 	`
-	rows, err := db.Query(sql, tournamentID, poolIndex)
+	rows, err := db.Query(sql, tournamentID, poolIndex, excluded)
 	if err != nil {
 		panic(err)
 	}
@@ -285,7 +578,7 @@ func selectTournamentPoolRanking(db *sql.DB, tournamentID string, poolIndex int)
 	slice := make([]teamRanking, 0)
 	for rows.Next() {
 		row := teamRanking{}
-		err2 := rows.Scan(&row.ID, &row.Name, &row.Played, &row.Wins, &row.Draws, &row.Defeats, &row.TeamGoals, &row.OpponentGoals, &row.GoalBalance, &row.Points, &row.Rank, &row.AttackRank, &row.DefenseRank)
+		err2 := rows.Scan(&row.ID, &row.Name, &row.Played, &row.Wins, &row.Draws, &row.Defeats, &row.TeamGoals, &row.OpponentGoals, &row.GoalBalance, &row.Points, &row.FairPlayPoints, &row.Rank, &row.AttackRank, &row.DefenseRank)
 		if err2 != nil {
 			panic(err2)
 		}
@@ -296,13 +589,22 @@ func selectTournamentPoolRanking(db *sql.DB, tournamentID string, poolIndex int)
 
 func selectTournament(db *sql.DB, tournamentID string) tournament {
 	sql := `
-		SELECT id, name
+		SELECT id, name, status, deleted_at, game_duration_minutes, min_rest_minutes, playing_windows, locale,
+			forfeit_goals, forfeit_penalty_points, forfeit_goals_counted, scoring_model,
+			yellow_card_points, red_card_points, yellow_cards_per_suspension, fair_play_tie_break, date, time_zone,
+			points_per_win, points_per_draw, points_per_defeat, points_per_goal, normalisation, eliminated_ranking
 		FROM tournament
 		WHERE id = $1
 	`
 	row := db.QueryRow(sql, tournamentID)
 	tournament := tournament{}
-	err2 := row.Scan(&tournament.ID, &tournament.Name)
+	err2 := row.Scan(&tournament.ID, &tournament.Name, &tournament.Status, &tournament.DeletedAt,
+		&tournament.GameDurationMinutes, &tournament.MinRestMinutes, &tournament.PlayingWindows, &tournament.Locale,
+		&tournament.ForfeitGoals, &tournament.ForfeitPenaltyPoints, &tournament.ForfeitGoalsCounted, &tournament.ScoringModel,
+		&tournament.YellowCardPoints, &tournament.RedCardPoints, &tournament.YellowCardsPerSuspension, &tournament.FairPlayTieBreak,
+		&tournament.Date, &tournament.TimeZone,
+		&tournament.pointsPerWin, &tournament.pointsPerDraw, &tournament.pointsPerDefeat, &tournament.pointsPerGoal, &tournament.Normalisation,
+		&tournament.EliminatedRanking)
 	if err2 != nil {
 		panic(err2)
 	}
@@ -310,7 +612,7 @@ func selectTournament(db *sql.DB, tournamentID string) tournament {
 }
 func selectTournamentTeams(db *sql.DB, tournamentID string) []team {
 	sql := `
-		SELECT team.id, team.name, team.pool_index
+		SELECT team.id, team.name, team.pool_index, team.withdrawn
 		FROM team 
 		JOIN tournament ON tournament.id = team.tournament_id
 		WHERE tournament.id = $1
@@ -318,6 +620,49 @@ func selectTournamentTeams(db *sql.DB, tournamentID string) []team {
 	`
 	return fetchTeams(db.Query(sql, tournamentID))
 }
+func selectTournamentPitches(db *sql.DB, tournamentID string) []pitch {
+	sql := `
+		SELECT id, name
+		FROM pitch
+		WHERE tournament_id = $1
+		ORDER BY id
+	`
+	rows, err := db.Query(sql, tournamentID)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+	slice := make([]pitch, 0)
+	for rows.Next() {
+		row := pitch{}
+		err2 := rows.Scan(&row.ID, &row.Name)
+		if err2 != nil {
+			panic(err2)
+		}
+		slice = append(slice, row)
+	}
+	return slice
+}
+
+// countMatchesWithoutPitch counts pool and ranking matches whose pitch does not exist in the tournament.
+func countMatchesWithoutPitch(db *sql.DB, tournamentID string) int {
+	sql := `
+		SELECT COUNT(*) FROM (
+			SELECT pitch_id FROM pool_match WHERE tournament_id = $1
+			UNION ALL
+			SELECT pitch_id FROM ranking_match WHERE tournament_id = $1
+		) match
+		LEFT JOIN pitch ON match.pitch_id = pitch.id AND pitch.tournament_id = $1
+		WHERE pitch.id IS NULL
+	`
+	var count int
+	err := db.QueryRow(sql, tournamentID).Scan(&count)
+	if err != nil {
+		panic(err)
+	}
+	return count
+}
+
 func selectTournamentPools(db *sql.DB, tournamentID string) []pool {
 	sql := `
 		SELECT tournament_id, pool_index, name
@@ -357,7 +702,7 @@ func selectTournamentPool(db *sql.DB, tournamentID string, poolIndex int) pool {
 }
 func selectTournamentPoolTeams(db *sql.DB, tournamentID string, poolIndex int) []team {
 	sql := `
-		SELECT team.id, team.name, team.pool_index
+		SELECT team.id, team.name, team.pool_index, team.withdrawn
 		FROM team 
 		JOIN tournament ON tournament.id = team.tournament_id
 		WHERE tournament.id = $1 AND team.pool_index = $2
@@ -374,7 +719,7 @@ func fetchTeams(rows *sql.Rows, err error) []team {
 	slice := make([]team, 0)
 	for rows.Next() {
 		row := team{}
-		err2 := rows.Scan(&row.ID, &row.Name, &row.PoolIndex)
+		err2 := rows.Scan(&row.ID, &row.Name, &row.PoolIndex, &row.Withdrawn)
 		if err2 != nil {
 			panic(err2)
 		}
@@ -386,11 +731,25 @@ func fetchTeams(rows *sql.Rows, err error) []team {
 
 func selectTournaments(db *sql.DB) []tournament {
 	sql := `
-		SELECT id, name
+		SELECT id, name, status, deleted_at, date
 		FROM tournament
+		WHERE deleted_at IS NULL
 		ORDER BY id	
 	`
-	rows, err := db.Query(sql)
+	return fetchTournaments(db.Query(sql))
+}
+
+func selectDeletedTournaments(db *sql.DB) []tournament {
+	sql := `
+		SELECT id, name, status, deleted_at, date
+		FROM tournament
+		WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+	`
+	return fetchTournaments(db.Query(sql))
+}
+
+func fetchTournaments(rows *sql.Rows, err error) []tournament {
 	if err != nil {
 		panic(err)
 	}
@@ -398,7 +757,7 @@ func selectTournaments(db *sql.DB) []tournament {
 	slice := make([]tournament, 0)
 	for rows.Next() {
 		row := tournament{}
-		err2 := rows.Scan(&row.ID, &row.Name)
+		err2 := rows.Scan(&row.ID, &row.Name, &row.Status, &row.DeletedAt, &row.Date)
 		if err2 != nil {
 			panic(err2)
 		}
@@ -409,43 +768,57 @@ func selectTournaments(db *sql.DB) []tournament {
 
 func insertTournament(db *sql.DB, t tournament) {
 	sql := `
-		INSERT INTO tournament(id, name, points_per_win, points_per_draw, points_per_defeat, points_per_goal)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO tournament(id, name, points_per_win, points_per_draw, points_per_defeat, points_per_goal, status,
+			game_duration_minutes, min_rest_minutes, playing_windows, locale, scoring_model, date, normalisation)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	`
-	_, err := db.Exec(sql, t.ID, t.Name, t.pointsPerWin, t.pointsPerDraw, t.pointsPerDefeat, t.pointsPerGoal)
+	_, err := db.Exec(sql, t.ID, t.Name, t.pointsPerWin, t.pointsPerDraw, t.pointsPerDefeat, t.pointsPerGoal, t.Status,
+		t.GameDurationMinutes, t.MinRestMinutes, t.PlayingWindows, t.Locale, t.ScoringModel, t.Date, t.Normalisation)
 	if err != nil {
 		panic(err)
 	}
 }
 func insertPool(db *sql.DB, p pool) {
 	sql := `
-		INSERT INTO pool(tournament_id, pool_index)
-		VALUES ($1, $2)
+		INSERT INTO pool(tournament_id, pool_index, name)
+		VALUES ($1, $2, $3)
 	`
-	_, err := db.Exec(sql, p.TournamentID, p.Index)
+	_, err := db.Exec(sql, p.TournamentID, p.Index, p.Name)
 	if err != nil {
 		panic(err)
 	}
 }
 func insertTeams(db *sql.DB, tournamentID string, teams []team) {
 	sql := `
-		INSERT INTO team(tournament_id, name, pool_index)
-		VALUES ($1, $2, $3)
+		INSERT INTO team(id, tournament_id, name, pool_index)
+		VALUES ($1, $2, $3, $4)
 	`
 	for _, team := range teams {
-		_, err := db.Exec(sql, tournamentID, team.Name, team.PoolIndex)
+		_, err := db.Exec(sql, team.ID, tournamentID, team.Name, team.PoolIndex)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+func insertPitches(db *sql.DB, tournamentID string, pitches []pitch) {
+	sql := `
+		INSERT INTO pitch(id, name, tournament_id)
+		VALUES ($1, $2, $3)
+	`
+	for _, pitch := range pitches {
+		_, err := db.Exec(sql, pitch.ID, pitch.Name, tournamentID)
 		if err != nil {
 			panic(err)
 		}
 	}
 }
-func updateTeamNames(db *sql.DB, teams []team) {
+func updateTeamNames(db *sql.DB, tournamentID string, teams []team) {
 	sql := `
 		UPDATE team SET name = $1
-		WHERE id = $2
+		WHERE tournament_id = $2 AND id = $3
 	`
 	for _, team := range teams {
-		_, err := db.Exec(sql, team.Name, team.ID)
+		_, err := db.Exec(sql, team.Name, tournamentID, team.ID)
 		if err != nil {
 			panic(err)
 		}
@@ -453,128 +826,861 @@ func updateTeamNames(db *sql.DB, teams []team) {
 }
 func insertPoolMatches(db *sql.DB, tournamentID string, matches []poolMatch) {
 	sql := `
-		INSERT INTO match(tournament_id, pool_index, scheduled_at, home_team_id, visitor_team_id)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO pool_match(id, tournament_id, pool_index, scheduled_at, pitch_id, home_team_id, visitor_team_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
 	for _, match := range matches {
-		_, err := db.Exec(sql, tournamentID, match.PoolIndex, match.ScheduledAt.Format(timeFormat), match.HomeTeamID, match.VisitorTeamID)
+		_, err := db.Exec(sql, match.ID, tournamentID, match.PoolIndex, formatScheduledAt(match.ScheduledAt), match.PitchID, match.HomeTeamID, match.VisitorTeamID)
 		if err != nil {
 			panic(err)
 		}
 	}
 }
-func deleteTournament(db *sql.DB, tournamentID string) {
-	sql := "DELETE FROM match WHERE tournament_id = $1"
-	_, err := db.Exec(sql, tournamentID)
+func tournamentExists(db *sql.DB, tournamentID string) bool {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM tournament WHERE id = $1", tournamentID).Scan(&count)
 	if err != nil {
 		panic(err)
 	}
-	sql = "DELETE FROM team WHERE tournament_id = $1"
-	_, err = db.Exec(sql, tournamentID)
+	return count > 0
+}
+
+func selectTournamentStartTime(db *sql.DB, tournamentID string) time.Time {
+	var startTime sql.NullString
+	sql := `
+		SELECT MIN(scheduled_at) FROM (
+			SELECT scheduled_at FROM pool_match WHERE tournament_id = $1
+			UNION ALL
+			SELECT scheduled_at FROM ranking_match WHERE tournament_id = $1
+		)
+	`
+	err := db.QueryRow(sql, tournamentID).Scan(&startTime)
 	if err != nil {
 		panic(err)
 	}
-	sql = "DELETE FROM pool WHERE tournament_id = $1"
-	_, err = db.Exec(sql, tournamentID)
-	if err != nil {
-		panic(err)
+	return parseScheduledAt(startTime.String)
+}
+
+// cloneTournament copies the whole structure of a tournament (settings, pitches, pools, teams,
+// pool matches and ranking matches) under a new ID. Scores and resolved ranking match teams are
+// not copied, and every match is shifted by offset.
+func cloneTournament(db *sql.DB, sourceID string, tournamentID string, name string, keepTeamNames bool, offset time.Duration) {
+	shift := fmt.Sprintf("%+d minutes", int(offset.Minutes()))
+	start := selectTournamentStartTime(db, sourceID)
+	days := int(start.Add(offset).Truncate(24*time.Hour).Sub(start.Truncate(24*time.Hour)).Hours() / 24)
+	statements := []struct {
+		sql  string
+		args []interface{}
+	}{
+		{`
+			INSERT INTO tournament(id, name, points_per_win, points_per_draw, points_per_defeat, points_per_goal, status,
+				game_duration_minutes, min_rest_minutes, playing_windows, locale,
+				forfeit_goals, forfeit_penalty_points, forfeit_goals_counted, scoring_model,
+				yellow_card_points, red_card_points, yellow_cards_per_suspension, fair_play_tie_break, date, time_zone, normalisation,
+				eliminated_ranking)
+			SELECT $1, $2, points_per_win, points_per_draw, points_per_defeat, points_per_goal, $3,
+				game_duration_minutes, min_rest_minutes, playing_windows, locale,
+				forfeit_goals, forfeit_penalty_points, forfeit_goals_counted, scoring_model,
+				yellow_card_points, red_card_points, yellow_cards_per_suspension, fair_play_tie_break, date(date, $4), time_zone, normalisation,
+				eliminated_ranking
+			FROM tournament
+			WHERE id = $5
+		`, []interface{}{tournamentID, name, statusDraft, fmt.Sprintf("%+d days", days), sourceID}},
+		{`
+			INSERT INTO pitch(id, name, tournament_id)
+			SELECT id, name, $1
+			FROM pitch
+			WHERE tournament_id = $2
+		`, []interface{}{tournamentID, sourceID}},
+		{`
+			INSERT INTO pool(tournament_id, pool_index, name)
+			SELECT $1, pool_index, name
+			FROM pool
+			WHERE tournament_id = $2
+		`, []interface{}{tournamentID, sourceID}},
+		{`
+			INSERT INTO team(id, tournament_id, pool_index, name)
+			SELECT id, $1, pool_index,
+				CASE WHEN $2 THEN name ELSE 'Team ' || ROW_NUMBER() OVER (ORDER BY pool_index, id) END
+			FROM team
+			WHERE tournament_id = $3
+		`, []interface{}{tournamentID, keepTeamNames, sourceID}},
+		{`
+			INSERT INTO player(tournament_id, team_id, name, number, licence_id)
+			SELECT $1, team_id, name, number, licence_id
+			FROM player
+			WHERE tournament_id = $2 AND $3
+			ORDER BY id
+		`, []interface{}{tournamentID, sourceID, keepTeamNames}},
+		{`
+			INSERT INTO pool_match(id, tournament_id, pool_index, scheduled_at, pitch_id, home_team_id, visitor_team_id)
+			SELECT id, $1, pool_index, strftime('%Y-%m-%d %H:%M', scheduled_at, $2), pitch_id, home_team_id, visitor_team_id
+			FROM pool_match
+			WHERE tournament_id = $3
+		`, []interface{}{tournamentID, shift, sourceID}},
+		{`
+			INSERT INTO ranking_match(key, tournament_id, scheduled_at, pitch_id,
+				home_team_pool_index, home_team_pool_rank, home_team_source_ranking_match, home_team_source_ranking_match_winner,
+				visitor_team_pool_index, visitor_team_pool_rank, visitor_team_source_ranking_match, visitor_team_source_ranking_match_winner,
+				looser_final_rank, winner_final_rank,
+				home_team_best_rank, home_team_best_position, visitor_team_best_rank, visitor_team_best_position)
+			SELECT key, $1, strftime('%Y-%m-%d %H:%M', scheduled_at, $2), pitch_id,
+				home_team_pool_index, home_team_pool_rank, home_team_source_ranking_match, home_team_source_ranking_match_winner,
+				visitor_team_pool_index, visitor_team_pool_rank, visitor_team_source_ranking_match, visitor_team_source_ranking_match_winner,
+				looser_final_rank, winner_final_rank,
+				home_team_best_rank, home_team_best_position, visitor_team_best_rank, visitor_team_best_position
+			FROM ranking_match
+			WHERE tournament_id = $3
+		`, []interface{}{tournamentID, shift, sourceID}},
 	}
-	sql = "DELETE FROM tournament WHERE id = $1"
-	_, err = db.Exec(sql, tournamentID)
+	tx, err := db.Begin()
 	if err != nil {
 		panic(err)
 	}
-}
-
-func savePoolMatchScore(db *sql.DB, tournamentID string,  matchID int, homeTeamGoals int, visitorTeamGoals int) {
-	sql := "UPDATE pool_match SET home_team_goals=$1, visitor_team_goals=$2 WHERE tournament_id = $3 AND id = $4"
-	_, err := db.Exec(sql, homeTeamGoals, visitorTeamGoals, tournamentID,  matchID)
+	for _, statement := range statements {
+		_, err = tx.Exec(statement.sql, statement.args...)
+		if err != nil {
+			tx.Rollback()
+			panic(err)
+		}
+	}
+	err = tx.Commit()
 	if err != nil {
 		panic(err)
 	}
 }
 
-func saveRankingMatchScore(db *sql.DB, tournamentID string, key string, homeTeamGoals int, visitorTeamGoals int, winnerTeamID int, looserTeamID int) {
-	sql := "UPDATE ranking_match SET home_team_goals=$1, visitor_team_goals=$2, winner_team_id=$3, looser_team_id=$4 WHERE tournament_id=$5 AND key = $6"
-	_, err := db.Exec(sql, homeTeamGoals, visitorTeamGoals, winnerTeamID, looserTeamID, tournamentID, key)
+func updateTournamentForfeitSettings(db *sql.DB, t tournament) {
+	sql := `
+		UPDATE tournament SET forfeit_goals = $1, forfeit_penalty_points = $2, forfeit_goals_counted = $3
+		WHERE id = $4
+	`
+	_, err := db.Exec(sql, t.ForfeitGoals, t.ForfeitPenaltyPoints, t.ForfeitGoalsCounted, t.ID)
 	if err != nil {
 		panic(err)
 	}
 }
 
-func countPoolMatchesToBePlayed(db *sql.DB, tournamentID string, poolIndex int) int {
+func updateTournamentFairPlaySettings(db *sql.DB, t tournament) {
 	sql := `
-	SELECT COUNT(*)
-	FROM pool_match
-	WHERE tournament_id = $1
-		AND pool_index = $2
-		AND (home_team_goals IS NULL OR visitor_team_goals IS NULL)
+		UPDATE tournament SET yellow_card_points = $1, red_card_points = $2, yellow_cards_per_suspension = $3, fair_play_tie_break = $4
+		WHERE id = $5
 	`
-	rows, err := db.Query(sql, tournamentID, poolIndex)
+	_, err := db.Exec(sql, t.YellowCardPoints, t.RedCardPoints, t.YellowCardsPerSuspension, t.FairPlayTieBreak, t.ID)
 	if err != nil {
 		panic(err)
 	}
-	defer rows.Close()
-	rows.Next()
-	var count int
-	err = rows.Scan(&count)
+}
+
+// saveMatchSets replaces the sets of a match, the match being referenced as in poolMatchRef and rankingMatchRef.
+func saveMatchSets(db *sql.DB, tournamentID string, matchRef string, sets []setScore) {
+	_, err := db.Exec("DELETE FROM match_set WHERE tournament_id = $1 AND match_ref = $2", tournamentID, matchRef)
 	if err != nil {
 		panic(err)
 	}
-	return count
+	for i, set := range sets {
+		sql := "INSERT INTO match_set(tournament_id, match_ref, set_index, home_points, visitor_points) VALUES ($1, $2, $3, $4, $5)"
+		_, err = db.Exec(sql, tournamentID, matchRef, i+1, set.HomePoints, set.VisitorPoints)
+		if err != nil {
+			panic(err)
+		}
+	}
 }
 
-func selectRankingMatchTeamIDs(db *sql.DB, tournamentID string, key string) (int, int) {
+func selectMatchSets(db *sql.DB, tournamentID string) map[string][]setScore {
 	sql := `
-	SELECT home_team_id, visitor_team_id
-	FROM ranking_match
-	WHERE tournament_id = $1
-		AND key = $2
+		SELECT match_ref, home_points, visitor_points
+		FROM match_set
+		WHERE tournament_id = $1
+		ORDER BY match_ref, set_index
 	`
-	rows, err := db.Query(sql, tournamentID, key)
+	rows, err := db.Query(sql, tournamentID)
 	if err != nil {
 		panic(err)
 	}
 	defer rows.Close()
-	rows.Next()
-	var homeTeamID int
-	var visitorTeamID int
-	err = rows.Scan(&homeTeamID, &visitorTeamID)
-	if err != nil {
-		panic(err)
+	sets := make(map[string][]setScore)
+	for rows.Next() {
+		var matchRef string
+		var set setScore
+		err2 := rows.Scan(&matchRef, &set.HomePoints, &set.VisitorPoints)
+		if err2 != nil {
+			panic(err2)
+		}
+		sets[matchRef] = append(sets[matchRef], set)
 	}
-	return homeTeamID, visitorTeamID
+	return sets
 }
 
-func updateRankingMatchFromPoolRank(db *sql.DB, tournamentID string, poolIndex int, poolRank int, teamID int) {
+// selectPoolSetPoints sums the points won and lost in the sets of the played matches of a pool, by team.
+func selectPoolSetPoints(db *sql.DB, tournamentID string, poolIndex int) (map[int]int, map[int]int) {
 	sql := `
-	UPDATE ranking_match 
-	SET home_team_id=$1
-	WHERE tournament_id = $2
-	 AND home_team_pool_index = $3
-	 AND home_team_pool_rank = $4
+		WITH pool_set AS (
+			SELECT home_team_id, visitor_team_id, home_points, visitor_points
+			FROM pool_match
+			JOIN match_set ON match_set.tournament_id = pool_match.tournament_id
+				AND match_set.match_ref = 'pool-' || pool_match.pool_index || '-' || pool_match.id
+			WHERE pool_match.tournament_id = $1 AND pool_match.pool_index = $2 AND result_status = 'played'
+		)
+		SELECT home_team_id, SUM(home_points), SUM(visitor_points) FROM pool_set GROUP BY home_team_id
+		UNION ALL
+		SELECT visitor_team_id, SUM(visitor_points), SUM(home_points) FROM pool_set GROUP BY visitor_team_id
 	`
-	_, err := db.Exec(sql, teamID, tournamentID, poolIndex, poolRank)
+	rows, err := db.Query(sql, tournamentID, poolIndex)
 	if err != nil {
 		panic(err)
 	}
-	sql = `
-	UPDATE ranking_match 
-	SET visitor_team_id=$1
-	WHERE tournament_id = $2
-	 AND visitor_team_pool_index = $3
-	 AND visitor_team_pool_rank = $4
-	`
-	_, err = db.Exec(sql, teamID, tournamentID, poolIndex, poolRank)
-	if err != nil {
-		panic(err)
+	defer rows.Close()
+	won := make(map[int]int)
+	lost := make(map[int]int)
+	for rows.Next() {
+		var teamID, pointsWon, pointsLost int
+		err2 := rows.Scan(&teamID, &pointsWon, &pointsLost)
+		if err2 != nil {
+			panic(err2)
+		}
+		won[teamID] += pointsWon
+		lost[teamID] += pointsLost
 	}
+	return won, lost
+}
 
+func selectTeam(db *sql.DB, tournamentID string, teamID int) team {
+	sql := `
+		SELECT team.id, team.name, team.pool_index, team.withdrawn
+		FROM team
+		WHERE team.tournament_id = $1 AND team.id = $2
+	`
+	teams := fetchTeams(db.Query(sql, tournamentID, teamID))
+	if len(teams) == 0 {
+		panic(fmt.Sprintf("team %d not found in tournament %s", teamID, tournamentID))
+	}
+	return teams[0]
 }
 
-func updateRankingMatchFromSourceRankingMatch(db *sql.DB, tournamentID string, sourceMatchKey string, winnerTeamID int, looserTeamID int) {
-	_, err := db.Exec("UPDATE ranking_match SET home_team_id=$1 WHERE tournament_id = $2 AND home_team_source_ranking_match = $3 AND home_team_source_ranking_match_winner = true",
-		winnerTeamID, tournamentID, sourceMatchKey)
+func selectTeamPlayers(db *sql.DB, tournamentID string, teamID int) []player {
+	sql := `
+		SELECT id, team_id, name, number, licence_id
+		FROM player
+		WHERE tournament_id = $1 AND team_id = $2
+		ORDER BY number IS NULL, number, name
+	`
+	rows, err := db.Query(sql, tournamentID, teamID)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+	slice := make([]player, 0)
+	for rows.Next() {
+		row := player{}
+		err2 := rows.Scan(&row.ID, &row.TeamID, &row.Name, &row.Number, &row.LicenceID)
+		if err2 != nil {
+			panic(err2)
+		}
+		slice = append(slice, row)
+	}
+	return slice
+}
+
+func insertPlayer(db *sql.DB, tournamentID string, p player) {
+	sql := "INSERT INTO player(tournament_id, team_id, name, number, licence_id) VALUES ($1, $2, $3, $4, $5)"
+	_, err := db.Exec(sql, tournamentID, p.TeamID, p.Name, p.Number, p.LicenceID)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func deletePlayer(db *sql.DB, tournamentID string, playerID int) {
+	_, err := db.Exec("DELETE FROM player WHERE tournament_id = $1 AND id = $2", tournamentID, playerID)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func countPlayerGoals(db *sql.DB, tournamentID string, playerID int) int {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM goal WHERE tournament_id = $1 AND player_id = $2", tournamentID, playerID).Scan(&count)
+	if err != nil {
+		panic(err)
+	}
+	return count
+}
+
+// saveMatchGoals replaces the goals of a match, the match being referenced as in poolMatchRef and rankingMatchRef.
+func saveMatchGoals(db *sql.DB, tournamentID string, matchRef string, goals []matchGoal) {
+	_, err := db.Exec("DELETE FROM goal WHERE tournament_id = $1 AND match_ref = $2", tournamentID, matchRef)
+	if err != nil {
+		panic(err)
+	}
+	for i, goal := range goals {
+		sql := "INSERT INTO goal(tournament_id, match_ref, goal_index, team_id, player_id, minute) VALUES ($1, $2, $3, $4, $5, $6)"
+		_, err = db.Exec(sql, tournamentID, matchRef, i+1, goal.TeamID, goal.PlayerID, goal.Minute)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+func selectMatchGoals(db *sql.DB, tournamentID string, matchRef string) []matchGoal {
+	sql := `
+		SELECT team_id, player_id, minute
+		FROM goal
+		WHERE tournament_id = $1 AND match_ref = $2
+		ORDER BY minute IS NULL, minute, goal_index
+	`
+	rows, err := db.Query(sql, tournamentID, matchRef)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+	slice := make([]matchGoal, 0)
+	for rows.Next() {
+		row := matchGoal{}
+		err2 := rows.Scan(&row.TeamID, &row.PlayerID, &row.Minute)
+		if err2 != nil {
+			panic(err2)
+		}
+		slice = append(slice, row)
+	}
+	return slice
+}
+
+func selectTournamentTopScorers(db *sql.DB, tournamentID string) []topScorer {
+	sql := `
+		SELECT player.name, player.number, team.name, COUNT(*) AS goals, 1
+		FROM goal
+		JOIN player ON player.id = goal.player_id
+		JOIN team ON team.tournament_id = player.tournament_id AND team.id = player.team_id
+		WHERE goal.tournament_id = $1
+		GROUP BY player.id
+		ORDER BY goals DESC, player.name
+	`
+	return fetchTopScorers(db.Query(sql, tournamentID))
+}
+
+// selectTopScorers adds up the goals of the public tournaments. Players sharing a licence ID are
+// the same person, the others are counted tournament by tournament.
+func selectTopScorers(db *sql.DB) []topScorer {
+	sql := `
+		SELECT MAX(player.name), NULL, GROUP_CONCAT(DISTINCT team.name), COUNT(*) AS goals, COUNT(DISTINCT goal.tournament_id)
+		FROM goal
+		JOIN player ON player.id = goal.player_id
+		JOIN team ON team.tournament_id = player.tournament_id AND team.id = player.team_id
+		JOIN tournament ON tournament.id = goal.tournament_id
+		WHERE tournament.deleted_at IS NULL AND tournament.status != 'draft'
+		GROUP BY CASE WHEN player.licence_id = '' THEN 'player-' || player.id ELSE player.licence_id END
+		ORDER BY goals DESC, MAX(player.name)
+	`
+	return fetchTopScorers(db.Query(sql))
+}
+
+func fetchTopScorers(rows *sql.Rows, err error) []topScorer {
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+	slice := make([]topScorer, 0)
+	for rows.Next() {
+		row := topScorer{}
+		err2 := rows.Scan(&row.PlayerName, &row.Number, &row.TeamName, &row.Goals, &row.Tournaments)
+		if err2 != nil {
+			panic(err2)
+		}
+		if len(slice) > 0 && slice[len(slice)-1].Goals == row.Goals {
+			row.Rank = slice[len(slice)-1].Rank
+		} else {
+			row.Rank = len(slice) + 1
+		}
+		slice = append(slice, row)
+	}
+	return slice
+}
+
+func insertCard(db *sql.DB, tournamentID string, c card) {
+	sql := "INSERT INTO card(tournament_id, match_ref, team_id, player_id, card_type, minute) VALUES ($1, $2, $3, $4, $5, $6)"
+	_, err := db.Exec(sql, tournamentID, c.MatchRef, c.TeamID, c.PlayerID, c.Type, c.Minute)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func deleteCard(db *sql.DB, tournamentID string, cardID int) {
+	_, err := db.Exec("DELETE FROM card WHERE tournament_id = $1 AND id = $2", tournamentID, cardID)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func selectTournamentCards(db *sql.DB, tournamentID string) []card {
+	sql := `
+		SELECT card.id, card.match_ref, card.team_id, team.name, card.player_id, player.name, card.card_type, card.minute
+		FROM card
+		JOIN team ON team.tournament_id = card.tournament_id AND team.id = card.team_id
+		LEFT JOIN player ON player.id = card.player_id
+		WHERE card.tournament_id = $1
+		ORDER BY card.match_ref, card.minute IS NULL, card.minute, card.id
+	`
+	rows, err := db.Query(sql, tournamentID)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+	slice := make([]card, 0)
+	for rows.Next() {
+		row := card{}
+		err2 := rows.Scan(&row.ID, &row.MatchRef, &row.TeamID, &row.TeamName, &row.PlayerID, &row.PlayerName, &row.Type, &row.Minute)
+		if err2 != nil {
+			panic(err2)
+		}
+		slice = append(slice, row)
+	}
+	return slice
+}
+
+// selectFairPlayTable sums the cards of every team of the tournament, the fewer points the better.
+func selectFairPlayTable(db *sql.DB, tournamentID string) []fairPlayRanking {
+	sql := `
+		WITH team_cards AS (
+			SELECT team_id,
+				SUM(card_type = 'yellow') AS yellow_cards,
+				SUM(card_type = 'red') AS red_cards,
+				SUM(CASE card_type WHEN 'red' THEN red_card_points ELSE yellow_card_points END) AS points
+			FROM card
+			JOIN tournament ON tournament.id = card.tournament_id
+			WHERE card.tournament_id = $1
+			GROUP BY team_id
+		)
+		SELECT
+			RANK() OVER (ORDER BY COALESCE(points, 0)) AS rank,
+			team.name,
+			COALESCE(yellow_cards, 0),
+			COALESCE(red_cards, 0),
+			COALESCE(points, 0)
+		FROM team
+		LEFT JOIN team_cards ON team_cards.team_id = team.id
+		WHERE team.tournament_id = $1
+		ORDER BY rank, team.name
+	`
+	rows, err := db.Query(sql, tournamentID)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+	slice := make([]fairPlayRanking, 0)
+	for rows.Next() {
+		row := fairPlayRanking{}
+		err2 := rows.Scan(&row.Rank, &row.TeamName, &row.YellowCards, &row.RedCards, &row.Points)
+		if err2 != nil {
+			panic(err2)
+		}
+		slice = append(slice, row)
+	}
+	return slice
+}
+
+func selectTournamentReferees(db *sql.DB, tournamentID string) []referee {
+	rows, err := db.Query("SELECT id, name FROM referee WHERE tournament_id = $1 ORDER BY name", tournamentID)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+	slice := make([]referee, 0)
+	for rows.Next() {
+		row := referee{}
+		err2 := rows.Scan(&row.ID, &row.Name)
+		if err2 != nil {
+			panic(err2)
+		}
+		slice = append(slice, row)
+	}
+	return slice
+}
+
+func insertReferee(db *sql.DB, tournamentID string, name string) {
+	_, err := db.Exec("INSERT INTO referee(tournament_id, name) VALUES ($1, $2)", tournamentID, name)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// deleteReferee removes the referee and its assignments.
+func deleteReferee(db *sql.DB, tournamentID string, refereeID int) {
+	_, err := db.Exec("DELETE FROM match_referee WHERE tournament_id = $1 AND referee_id = $2", tournamentID, refereeID)
+	if err != nil {
+		panic(err)
+	}
+	_, err = db.Exec("DELETE FROM referee WHERE tournament_id = $1 AND id = $2", tournamentID, refereeID)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func selectMatchOfficials(db *sql.DB, tournamentID string) map[string]official {
+	sql := `
+		SELECT match_ref, match_referee.referee_id, match_referee.referee_team_id, COALESCE(referee.name, team.name)
+		FROM match_referee
+		LEFT JOIN referee ON referee.id = match_referee.referee_id
+		LEFT JOIN team ON team.tournament_id = match_referee.tournament_id AND team.id = match_referee.referee_team_id
+		WHERE match_referee.tournament_id = $1
+	`
+	rows, err := db.Query(sql, tournamentID)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+	officials := make(map[string]official)
+	for rows.Next() {
+		var matchRef string
+		var o official
+		err2 := rows.Scan(&matchRef, &o.RefereeID, &o.TeamID, &o.Name)
+		if err2 != nil {
+			panic(err2)
+		}
+		officials[matchRef] = o
+	}
+	return officials
+}
+
+// saveMatchOfficial assigns the official to the match, an empty official leaving the match without referee.
+func saveMatchOfficial(db *sql.DB, tournamentID string, matchRef string, o official) {
+	_, err := db.Exec("DELETE FROM match_referee WHERE tournament_id = $1 AND match_ref = $2", tournamentID, matchRef)
+	if err != nil {
+		panic(err)
+	}
+	if o.Key() == "" {
+		return
+	}
+	sql := "INSERT INTO match_referee(tournament_id, match_ref, referee_id, referee_team_id) VALUES ($1, $2, $3, $4)"
+	_, err = db.Exec(sql, tournamentID, matchRef, o.RefereeID, o.TeamID)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func updateTeamWithdrawn(db *sql.DB, tournamentID string, teamID int) {
+	_, err := db.Exec("UPDATE team SET withdrawn = 1 WHERE tournament_id = $1 AND id = $2", tournamentID, teamID)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func updateTournamentNormalisation(db *sql.DB, tournamentID string, normalisation string) {
+	sql := `
+		UPDATE tournament SET normalisation = $1
+		WHERE id = $2
+	`
+	_, err := db.Exec(sql, normalisation, tournamentID)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func updateTournamentEliminatedRanking(db *sql.DB, tournamentID string, eliminatedRanking string) {
+	sql := `
+		UPDATE tournament SET eliminated_ranking = $1
+		WHERE id = $2
+	`
+	_, err := db.Exec(sql, eliminatedRanking, tournamentID)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func updateTournamentLocale(db *sql.DB, tournamentID string, locale string) {
+	sql := `
+		UPDATE tournament SET locale = $1
+		WHERE id = $2
+	`
+	_, err := db.Exec(sql, locale, tournamentID)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func updateTournamentScheduleSettings(db *sql.DB, t tournament) {
+	sql := `
+		UPDATE tournament SET game_duration_minutes = $1, min_rest_minutes = $2, playing_windows = $3, date = $4, time_zone = $5
+		WHERE id = $6
+	`
+	_, err := db.Exec(sql, t.GameDurationMinutes, t.MinRestMinutes, t.PlayingWindows, t.Date, t.TimeZone, t.ID)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// shiftTournamentDays moves every match of the tournament by the given number of days.
+func shiftTournamentDays(db *sql.DB, tournamentID string, days int) {
+	shift := fmt.Sprintf("%+d days", days)
+	for _, table := range []string{"pool_match", "ranking_match"} {
+		sql := `
+			UPDATE ` + table + `
+			SET scheduled_at = strftime('%Y-%m-%d %H:%M', scheduled_at, $1)
+			WHERE tournament_id = $2
+		`
+		_, err := db.Exec(sql, shift, tournamentID)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+func updateTournamentStatus(db *sql.DB, tournamentID string, status string) {
+	_, err := db.Exec("UPDATE tournament SET status = $1 WHERE id = $2", status, tournamentID)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func trashTournament(db *sql.DB, tournamentID string) {
+	_, err := db.Exec("UPDATE tournament SET deleted_at = datetime('now') WHERE id = $1", tournamentID)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func restoreTournament(db *sql.DB, tournamentID string) {
+	_, err := db.Exec("UPDATE tournament SET deleted_at = NULL WHERE id = $1", tournamentID)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func deleteTournament(db *sql.DB, tournamentID string) {
+	sql := "DELETE FROM match_set WHERE tournament_id = $1"
+	_, err := db.Exec(sql, tournamentID)
+	if err != nil {
+		panic(err)
+	}
+	sql = "DELETE FROM display_tournament WHERE tournament_id = $1"
+	_, err = db.Exec(sql, tournamentID)
+	if err != nil {
+		panic(err)
+	}
+	sql = "DELETE FROM webhook_delivery WHERE webhook_id IN (SELECT id FROM webhook WHERE tournament_id = $1)"
+	_, err = db.Exec(sql, tournamentID)
+	if err != nil {
+		panic(err)
+	}
+	sql = "DELETE FROM webhook WHERE tournament_id = $1"
+	_, err = db.Exec(sql, tournamentID)
+	if err != nil {
+		panic(err)
+	}
+	sql = "DELETE FROM score_submission WHERE tournament_id = $1"
+	_, err = db.Exec(sql, tournamentID)
+	if err != nil {
+		panic(err)
+	}
+	sql = "DELETE FROM live_match WHERE tournament_id = $1"
+	_, err = db.Exec(sql, tournamentID)
+	if err != nil {
+		panic(err)
+	}
+	sql = "DELETE FROM match_referee WHERE tournament_id = $1"
+	_, err = db.Exec(sql, tournamentID)
+	if err != nil {
+		panic(err)
+	}
+	sql = "DELETE FROM referee WHERE tournament_id = $1"
+	_, err = db.Exec(sql, tournamentID)
+	if err != nil {
+		panic(err)
+	}
+	sql = "DELETE FROM card WHERE tournament_id = $1"
+	_, err = db.Exec(sql, tournamentID)
+	if err != nil {
+		panic(err)
+	}
+	sql = "DELETE FROM goal WHERE tournament_id = $1"
+	_, err = db.Exec(sql, tournamentID)
+	if err != nil {
+		panic(err)
+	}
+	sql = "DELETE FROM player WHERE tournament_id = $1"
+	_, err = db.Exec(sql, tournamentID)
+	if err != nil {
+		panic(err)
+	}
+	sql = "DELETE FROM pool_match WHERE tournament_id = $1"
+	_, err = db.Exec(sql, tournamentID)
+	if err != nil {
+		panic(err)
+	}
+	sql = "DELETE FROM ranking_match WHERE tournament_id = $1"
+	_, err = db.Exec(sql, tournamentID)
+	if err != nil {
+		panic(err)
+	}
+	sql = "DELETE FROM pitch WHERE tournament_id = $1"
+	_, err = db.Exec(sql, tournamentID)
+	if err != nil {
+		panic(err)
+	}
+	sql = "DELETE FROM team WHERE tournament_id = $1"
+	_, err = db.Exec(sql, tournamentID)
+	if err != nil {
+		panic(err)
+	}
+	sql = "DELETE FROM pool WHERE tournament_id = $1"
+	_, err = db.Exec(sql, tournamentID)
+	if err != nil {
+		panic(err)
+	}
+	sql = "DELETE FROM tournament WHERE id = $1"
+	_, err = db.Exec(sql, tournamentID)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// savePoolMatchScore saves the final score of the match, which ends it when it was followed live. Scorers
+// not adding up to the new score are deleted.
+func savePoolMatchScore(db *sql.DB, tournamentID string, poolIndex int, matchID int, resultStatus string, homeTeamGoals int, visitorTeamGoals int) {
+	sql := "UPDATE pool_match SET home_team_goals=$1, visitor_team_goals=$2, result_status=$3 WHERE tournament_id = $4 AND pool_index = $5 AND id = $6"
+	_, err := db.Exec(sql, homeTeamGoals, visitorTeamGoals, resultStatus, tournamentID, poolIndex, matchID)
+	if err != nil {
+		panic(err)
+	}
+	finishLiveMatch(db, tournamentID, poolMatchRef(poolIndex, matchID))
+	discardStaleGoals(db, tournamentID, poolMatchRef(poolIndex, matchID))
+}
+
+// saveRankingMatchScore saves the final score of the match, which ends it when it was followed live. Scorers
+// not adding up to the new score are deleted.
+func saveRankingMatchScore(db *sql.DB, tournamentID string, key string, resultStatus string, score rankingMatchScore, winnerTeamID int, looserTeamID int) {
+	sql := `
+		UPDATE ranking_match SET home_team_goals=$1, visitor_team_goals=$2,
+			home_team_extra_time_goals=$3, visitor_team_extra_time_goals=$4, home_team_penalty_goals=$5, visitor_team_penalty_goals=$6,
+			result_status=$7, winner_team_id=$8, looser_team_id=$9
+		WHERE tournament_id=$10 AND key = $11
+	`
+	_, err := db.Exec(sql, score.HomeTeamGoals, score.VisitorTeamGoals,
+		score.HomeTeamExtraTimeGoals, score.VisitorTeamExtraTimeGoals, score.HomeTeamPenaltyGoals, score.VisitorTeamPenaltyGoals,
+		resultStatus, winnerTeamID, looserTeamID, tournamentID, key)
+	if err != nil {
+		panic(err)
+	}
+	finishLiveMatch(db, tournamentID, rankingMatchRef(key))
+	discardStaleGoals(db, tournamentID, rankingMatchRef(key))
+}
+
+func updatePoolMatchSlot(db *sql.DB, tournamentID string, poolIndex int, matchID int, scheduledAt time.Time, pitchID int) {
+	sql := "UPDATE pool_match SET scheduled_at=$1, pitch_id=$2 WHERE tournament_id=$3 AND pool_index=$4 AND id=$5"
+	_, err := db.Exec(sql, formatScheduledAt(scheduledAt), pitchID, tournamentID, poolIndex, matchID)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func updateRankingMatchSlot(db *sql.DB, tournamentID string, key string, scheduledAt time.Time, pitchID int) {
+	sql := "UPDATE ranking_match SET scheduled_at=$1, pitch_id=$2 WHERE tournament_id=$3 AND key=$4"
+	_, err := db.Exec(sql, formatScheduledAt(scheduledAt), pitchID, tournamentID, key)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// updateMatchSlots saves the time and pitch of matches moved or shifted in the schedule.
+func updateMatchSlots(db *sql.DB, tournamentID string, matches []scheduledMatch) {
+	for _, match := range matches {
+		poolIndex, matchID, key, _ := parseMatchRef(match.Ref)
+		if key != "" {
+			updateRankingMatchSlot(db, tournamentID, key, match.Start, match.PitchID)
+		} else {
+			updatePoolMatchSlot(db, tournamentID, poolIndex, matchID, match.Start, match.PitchID)
+		}
+	}
+}
+
+// countPoolMatchesToBePlayed counts the matches of the pool without final score. An abandoned match is over:
+// it is not replayed and the ranking leaves it out, so it does not hold the pool back.
+func countPoolMatchesToBePlayed(db *sql.DB, tournamentID string, poolIndex int) int {
+	sql := `
+	SELECT COUNT(*)
+	FROM pool_match
+	WHERE tournament_id = $1
+		AND pool_index = $2
+		AND result_status != 'abandoned'
+		AND (home_team_goals IS NULL OR visitor_team_goals IS NULL OR EXISTS (` + liveMatchInProgress + `))
+	`
+	rows, err := db.Query(sql, tournamentID, poolIndex)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+	rows.Next()
+	var count int
+	err = rows.Scan(&count)
+	if err != nil {
+		panic(err)
+	}
+	return count
+}
+
+func selectRankingMatchTeamIDs(db *sql.DB, tournamentID string, key string) (int, int) {
+	sql := `
+	SELECT home_team_id, visitor_team_id
+	FROM ranking_match
+	WHERE tournament_id = $1
+		AND key = $2
+	`
+	rows, err := db.Query(sql, tournamentID, key)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+	rows.Next()
+	var homeTeamID int
+	var visitorTeamID int
+	err = rows.Scan(&homeTeamID, &visitorTeamID)
+	if err != nil {
+		panic(err)
+	}
+	return homeTeamID, visitorTeamID
+}
+
+func updateRankingMatchFromBestRank(db *sql.DB, tournamentID string, rank int, position int, teamID int) {
+	_, err := db.Exec("UPDATE ranking_match SET home_team_id=$1 WHERE tournament_id = $2 AND home_team_best_rank = $3 AND home_team_best_position = $4",
+		teamID, tournamentID, rank, position)
+	if err != nil {
+		panic(err)
+	}
+	_, err = db.Exec("UPDATE ranking_match SET visitor_team_id=$1 WHERE tournament_id = $2 AND visitor_team_best_rank = $3 AND visitor_team_best_position = $4",
+		teamID, tournamentID, rank, position)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func updateRankingMatchFromPoolRank(db *sql.DB, tournamentID string, poolIndex int, poolRank int, teamID int) {
+	sql := `
+	UPDATE ranking_match 
+	SET home_team_id=$1
+	WHERE tournament_id = $2
+	 AND home_team_pool_index = $3
+	 AND home_team_pool_rank = $4
+	`
+	_, err := db.Exec(sql, teamID, tournamentID, poolIndex, poolRank)
+	if err != nil {
+		panic(err)
+	}
+	sql = `
+	UPDATE ranking_match 
+	SET visitor_team_id=$1
+	WHERE tournament_id = $2
+	 AND visitor_team_pool_index = $3
+	 AND visitor_team_pool_rank = $4
+	`
+	_, err = db.Exec(sql, teamID, tournamentID, poolIndex, poolRank)
+	if err != nil {
+		panic(err)
+	}
+
+}
+
+func updateRankingMatchFromSourceRankingMatch(db *sql.DB, tournamentID string, sourceMatchKey string, winnerTeamID int, looserTeamID int) {
+	_, err := db.Exec("UPDATE ranking_match SET home_team_id=$1 WHERE tournament_id = $2 AND home_team_source_ranking_match = $3 AND home_team_source_ranking_match_winner = true",
+		winnerTeamID, tournamentID, sourceMatchKey)
 	if err != nil {
 		panic(err)
 	}
@@ -595,7 +1701,14 @@ func updateRankingMatchFromSourceRankingMatch(db *sql.DB, tournamentID string, s
 	}
 }
 
-func selectTournamentFinalRanking(db *sql.DB, tournamentID string) []tournamentFinalRanking {
+// selectTournamentFinalRanking returns the final ranking, followed by the teams without final rank yet with
+// a zero rank. Attack and defense ranks leave out the pool matches against the excluded teams, and compare
+// goals per match when averaged.
+func selectTournamentFinalRanking(db *sql.DB, tournamentID string, excludedTeamIDs []int, averaged bool) []tournamentFinalRanking {
+	excluded := ","
+	for _, teamID := range excludedTeamIDs {
+		excluded += fmt.Sprintf("%d,", teamID)
+	}
 	sql := `
 	WITH final_match AS (
 		SELECT * FROM ranking_match WHERE tournament_id = $1 AND winner_final_rank IS NOT NULL
@@ -611,40 +1724,65 @@ func selectTournamentFinalRanking(db *sql.DB, tournamentID string) []tournamentF
 		SELECT winner_final_rank AS rank FROM ranking_match WHERE tournament_id = $1 AND winner_final_rank IS NOT NULL
 		UNION
 		SELECT looser_final_rank AS rank FROM ranking_match WHERE tournament_id = $1 AND looser_final_rank IS NOT NULL
+	), counted_pool_match AS (
+	  SELECT pool_match.*
+	  FROM pool_match
+	  JOIN tournament ON tournament.id = pool_match.tournament_id
+	  WHERE tournament_id=$1 AND (result_status = 'played' OR (forfeit_goals_counted AND result_status != 'abandoned'))
+		AND home_team_goals IS NOT NULL AND visitor_team_goals IS NOT NULL
+		AND NOT EXISTS (` + liveMatchInProgress + `)
+	), counted_ranking_match AS (
+	  SELECT ranking_match.*
+	  FROM ranking_match
+	  JOIN tournament ON tournament.id = ranking_match.tournament_id
+	  WHERE tournament_id=$1 AND (result_status = 'played' OR forfeit_goals_counted)
+		AND home_team_goals IS NOT NULL AND visitor_team_goals IS NOT NULL
+		AND NOT EXISTS (` + liveRankingMatchInProgress + `)
 	), all_matches AS (
 	  SELECT home_team_id AS team_id, home_team_goals AS team_goals, visitor_team_goals AS opponent_goals 
-	  FROM pool_match 
-	  WHERE tournament_id=$1
+	  FROM counted_pool_match 
+	  WHERE instr($2, ',' || visitor_team_id || ',') = 0
 	  UNION ALL
 	  SELECT visitor_team_id AS team_id, visitor_team_goals AS team_goals, home_team_goals AS opponent_goals 
-	  FROM pool_match 
-	  WHERE tournament_id=$1
+	  FROM counted_pool_match 
+	  WHERE instr($2, ',' || home_team_id || ',') = 0
 	  UNION ALL
-	  SELECT home_team_id AS team_id, home_team_goals AS team_goals, visitor_team_goals AS opponent_goals 
-	  FROM ranking_match 
-	  WHERE tournament_id=$1
+	  SELECT home_team_id AS team_id,
+		COALESCE(home_team_extra_time_goals, home_team_goals) AS team_goals,
+		COALESCE(visitor_team_extra_time_goals, visitor_team_goals) AS opponent_goals
+	  FROM counted_ranking_match 
 	  UNION ALL
-	  SELECT visitor_team_id AS team_id, visitor_team_goals AS team_goals, home_team_goals AS opponent_goals 
-	  FROM ranking_match 
-	  WHERE tournament_id=$1
+	  SELECT visitor_team_id AS team_id,
+		COALESCE(visitor_team_extra_time_goals, visitor_team_goals) AS team_goals,
+		COALESCE(home_team_extra_time_goals, home_team_goals) AS opponent_goals
+	  FROM counted_ranking_match 
 	), team_summary AS (
-	  SELECT team_id, SUM(team_goals) AS team_goals, SUM(opponent_goals) AS opponent_goals, (SUM(team_goals) - SUM(opponent_goals)) AS goal_balance
+	  SELECT team_id, COUNT(*) AS played, SUM(team_goals) AS team_goals, SUM(opponent_goals) AS opponent_goals, (SUM(team_goals) - SUM(opponent_goals)) AS goal_balance
 	  FROM all_matches
 	  GROUP BY team_id
 	), attack_defense_rank AS (
-   	  SELECT team_id, team_goals, opponent_goals, goal_balance,
-			RANK() OVER (ORDER BY team_goals DESC, goal_balance DESC) AS attack_rank,
-			RANK() OVER (ORDER BY opponent_goals ASC, goal_balance DESC) AS defense_rank
+   	  SELECT team_id, played, team_goals, opponent_goals, goal_balance,
+			RANK() OVER (ORDER BY CASE WHEN $3 THEN team_goals * 1.0 / played ELSE team_goals END DESC,
+				CASE WHEN $3 THEN goal_balance * 1.0 / played ELSE goal_balance END DESC) AS attack_rank,
+			RANK() OVER (ORDER BY CASE WHEN $3 THEN opponent_goals * 1.0 / played ELSE opponent_goals END ASC,
+				CASE WHEN $3 THEN goal_balance * 1.0 / played ELSE goal_balance END DESC) AS defense_rank
 	  FROM team_summary
     )
-	SELECT ranks.rank, team.name, team_goals, opponent_goals, goal_balance, attack_rank, defense_rank
-	FROM ranks
-	LEFT JOIN ranked_team ON ranks.rank = ranked_team.rank
-	LEFT JOIN team ON team.tournament_id = $1 AND team.id=ranked_team.team_id
+	SELECT * FROM (
+		SELECT ranks.rank, team.id, team.name, played, team_goals, opponent_goals, goal_balance, attack_rank, defense_rank
+		FROM ranks
+		LEFT JOIN ranked_team ON ranks.rank = ranked_team.rank
+		LEFT JOIN team ON team.tournament_id = $1 AND team.id=ranked_team.team_id
+		LEFT JOIN attack_defense_rank ON team.id = attack_defense_rank.team_id
+		ORDER by ranks.rank
+	)
+	UNION ALL
+	SELECT 0, team.id, team.name, played, team_goals, opponent_goals, goal_balance, attack_rank, defense_rank
+	FROM team
 	LEFT JOIN attack_defense_rank ON team.id = attack_defense_rank.team_id
-	ORDER by ranks.rank 
+	WHERE team.tournament_id = $1 AND team.id NOT IN (SELECT team_id FROM ranked_team)
 	`
-	rows, err := db.Query(sql, tournamentID)
+	rows, err := db.Query(sql, tournamentID, excluded, averaged)
 	if err != nil {
 		panic(err)
 	}
@@ -652,7 +1790,7 @@ func selectTournamentFinalRanking(db *sql.DB, tournamentID string) []tournamentF
 	slice := make([]tournamentFinalRanking, 0)
 	for rows.Next() {
 		row := tournamentFinalRanking{}
-		err2 := rows.Scan(&row.Rank, &row.TeamName, &row.TeamGoals, &row.OpponentGoals, &row.GoalBalance, &row.AttackRank, &row.DefenseRank)
+		err2 := rows.Scan(&row.Rank, &row.TeamID, &row.TeamName, &row.Played, &row.TeamGoals, &row.OpponentGoals, &row.GoalBalance, &row.AttackRank, &row.DefenseRank)
 		if err2 != nil {
 			panic(err2)
 		}
@@ -695,3 +1833,366 @@ func parseTime(timeStr string) time.Time {
 func formatTime(t time.Time) string {
 	return t.Format(timeFormat)
 }
+func parseScheduledAt(scheduledAt string) time.Time {
+	t, _ := time.Parse(scheduledAtFormat, scheduledAt)
+	return t
+}
+func formatScheduledAt(t time.Time) string {
+	return t.Format(scheduledAtFormat)
+}
+
+func selectDisplays(db *sql.DB) []display {
+	rows, err := db.Query("SELECT id, name, locale, slide_seconds, upcoming_minutes, latest_results FROM display ORDER BY name")
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+	slice := make([]display, 0)
+	for rows.Next() {
+		row := display{}
+		err2 := rows.Scan(&row.ID, &row.Name, &row.Locale, &row.SlideSeconds, &row.UpcomingMinutes, &row.LatestResults)
+		if err2 != nil {
+			panic(err2)
+		}
+		slice = append(slice, row)
+	}
+	return slice
+}
+
+// selectDisplay loads the display with its tournaments, sql.ErrNoRows meaning there is no such display.
+func selectDisplay(db *sql.DB, displayID int) (display, error) {
+	d := display{ID: displayID}
+	err := db.QueryRow("SELECT name, locale, slide_seconds, upcoming_minutes, latest_results FROM display WHERE id = $1", displayID).
+		Scan(&d.Name, &d.Locale, &d.SlideSeconds, &d.UpcomingMinutes, &d.LatestResults)
+	if err != nil {
+		return d, err
+	}
+	rows, err := db.Query("SELECT tournament_id FROM display_tournament WHERE display_id = $1 ORDER BY tournament_id", displayID)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+	d.TournamentIDs = make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			panic(err)
+		}
+		d.TournamentIDs = append(d.TournamentIDs, id)
+	}
+	return d, nil
+}
+
+func insertDisplay(db *sql.DB, name string, locale string) int {
+	result, err := db.Exec("INSERT INTO display(name, locale) VALUES ($1, $2)", name, locale)
+	if err != nil {
+		panic(err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		panic(err)
+	}
+	return int(id)
+}
+
+// updateDisplay saves the settings of the display and replaces its tournaments.
+func updateDisplay(db *sql.DB, d display) {
+	sql := `
+		UPDATE display SET name = $1, locale = $2, slide_seconds = $3, upcoming_minutes = $4, latest_results = $5
+		WHERE id = $6
+	`
+	_, err := db.Exec(sql, d.Name, d.Locale, d.SlideSeconds, d.UpcomingMinutes, d.LatestResults, d.ID)
+	if err != nil {
+		panic(err)
+	}
+	_, err = db.Exec("DELETE FROM display_tournament WHERE display_id = $1", d.ID)
+	if err != nil {
+		panic(err)
+	}
+	for _, tournamentID := range d.TournamentIDs {
+		_, err = db.Exec("INSERT INTO display_tournament(display_id, tournament_id) VALUES ($1, $2)", d.ID, tournamentID)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+func deleteDisplay(db *sql.DB, displayID int) {
+	for _, table := range []string{"display_slide", "display_tournament"} {
+		_, err := db.Exec("DELETE FROM "+table+" WHERE display_id = $1", displayID)
+		if err != nil {
+			panic(err)
+		}
+	}
+	_, err := db.Exec("DELETE FROM display WHERE id = $1", displayID)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func selectDisplaySlides(db *sql.DB, displayID int) []displaySlide {
+	rows, err := db.Query("SELECT id, kind, image_url, seconds FROM display_slide WHERE display_id = $1 ORDER BY id", displayID)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+	slice := make([]displaySlide, 0)
+	for rows.Next() {
+		row := displaySlide{}
+		err2 := rows.Scan(&row.ID, &row.Kind, &row.ImageURL, &row.Seconds)
+		if err2 != nil {
+			panic(err2)
+		}
+		slice = append(slice, row)
+	}
+	return slice
+}
+
+func insertDisplaySlide(db *sql.DB, displayID int, slide displaySlide) {
+	_, err := db.Exec("INSERT INTO display_slide(display_id, kind, image_url, seconds) VALUES ($1, $2, $3, $4)", displayID, slide.Kind, slide.ImageURL, slide.Seconds)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func deleteDisplaySlide(db *sql.DB, displayID int, slideID int) {
+	_, err := db.Exec("DELETE FROM display_slide WHERE display_id = $1 AND id = $2", displayID, slideID)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func selectTournamentWebhooks(db *sql.DB, tournamentID string) []webhook {
+	rows, err := db.Query("SELECT id, url, secret, events FROM webhook WHERE tournament_id = $1 ORDER BY id", tournamentID)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+	slice := make([]webhook, 0)
+	for rows.Next() {
+		row := webhook{TournamentID: tournamentID}
+		var events string
+		err2 := rows.Scan(&row.ID, &row.URL, &row.Secret, &events)
+		if err2 != nil {
+			panic(err2)
+		}
+		row.Events = strings.Split(events, ",")
+		slice = append(slice, row)
+	}
+	return slice
+}
+
+func insertWebhook(db *sql.DB, w webhook) {
+	_, err := db.Exec("INSERT INTO webhook(tournament_id, url, secret, events) VALUES ($1, $2, $3, $4)",
+		w.TournamentID, w.URL, w.Secret, strings.Join(w.Events, ","))
+	if err != nil {
+		panic(err)
+	}
+}
+
+func deleteWebhook(db *sql.DB, tournamentID string, webhookID int) {
+	sql := "DELETE FROM webhook_delivery WHERE webhook_id IN (SELECT id FROM webhook WHERE tournament_id = $1 AND id = $2)"
+	_, err := db.Exec(sql, tournamentID, webhookID)
+	if err != nil {
+		panic(err)
+	}
+	_, err = db.Exec("DELETE FROM webhook WHERE tournament_id = $1 AND id = $2", tournamentID, webhookID)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func insertWebhookDelivery(db *sql.DB, webhookID int, event string, payload string, now time.Time) {
+	sql := `
+		INSERT INTO webhook_delivery(webhook_id, event, payload, status, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+	`
+	_, err := db.Exec(sql, webhookID, event, payload, deliveryPending, now.UTC().Format(webhookTimeLayout))
+	if err != nil {
+		panic(err)
+	}
+}
+
+const webhookDeliveryColumns = `
+	delivery.id, delivery.webhook_id, webhook.url, webhook.secret, delivery.event, delivery.payload, delivery.status,
+	delivery.attempts, delivery.next_attempt_at, delivery.last_status_code, delivery.last_error, delivery.created_at
+`
+
+// selectDueWebhookDeliveries returns the pending deliveries whose next attempt is due, oldest first.
+func selectDueWebhookDeliveries(db *sql.DB, now time.Time) []webhookDelivery {
+	sql := "SELECT " + webhookDeliveryColumns + `
+		FROM webhook_delivery delivery
+		JOIN webhook ON webhook.id = delivery.webhook_id
+		WHERE delivery.status = $1 AND delivery.next_attempt_at <= $2
+		ORDER BY delivery.id
+	`
+	return fetchWebhookDeliveries(db.Query(sql, deliveryPending, now.UTC().Format(webhookTimeLayout)))
+}
+
+// selectTournamentWebhookDeliveries returns the latest deliveries of the webhooks of the tournament.
+func selectTournamentWebhookDeliveries(db *sql.DB, tournamentID string, limit int) []webhookDelivery {
+	sql := "SELECT " + webhookDeliveryColumns + `
+		FROM webhook_delivery delivery
+		JOIN webhook ON webhook.id = delivery.webhook_id
+		WHERE webhook.tournament_id = $1
+		ORDER BY delivery.id DESC
+		LIMIT $2
+	`
+	return fetchWebhookDeliveries(db.Query(sql, tournamentID, limit))
+}
+
+func fetchWebhookDeliveries(rows *sql.Rows, err error) []webhookDelivery {
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+	slice := make([]webhookDelivery, 0)
+	for rows.Next() {
+		row := webhookDelivery{}
+		var nextAttemptAt, createdAt string
+		err2 := rows.Scan(&row.ID, &row.WebhookID, &row.URL, &row.Secret, &row.Event, &row.Payload, &row.Status,
+			&row.Attempts, &nextAttemptAt, &row.LastStatusCode, &row.LastError, &createdAt)
+		if err2 != nil {
+			panic(err2)
+		}
+		row.NextAttemptAt, _ = time.Parse(webhookTimeLayout, nextAttemptAt)
+		row.CreatedAt, _ = time.Parse(webhookTimeLayout, createdAt)
+		slice = append(slice, row)
+	}
+	return slice
+}
+
+func updateWebhookDelivery(db *sql.DB, d webhookDelivery) {
+	sql := `
+		UPDATE webhook_delivery SET status = $1, attempts = $2, next_attempt_at = $3, last_status_code = $4, last_error = $5
+		WHERE id = $6
+	`
+	_, err := db.Exec(sql, d.Status, d.Attempts, d.NextAttemptAt.UTC().Format(webhookTimeLayout), d.LastStatusCode, d.LastError, d.ID)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// retryWebhookDelivery queues the delivery again for a whole new series of attempts.
+func retryWebhookDelivery(db *sql.DB, tournamentID string, deliveryID int, now time.Time) {
+	sql := `
+		UPDATE webhook_delivery SET status = $1, attempts = 0, next_attempt_at = $2
+		WHERE id = $3 AND webhook_id IN (SELECT id FROM webhook WHERE tournament_id = $4)
+	`
+	_, err := db.Exec(sql, deliveryPending, now.UTC().Format(webhookTimeLayout), deliveryID, tournamentID)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// claimScoreSubmission records the submission ID and tells whether it was new, so that only one of two
+// concurrent requests replaying the same submission applies it.
+func claimScoreSubmission(db *sql.DB, tournamentID string, submissionID string, ref string, now time.Time) bool {
+	sql := "INSERT OR IGNORE INTO score_submission(id, tournament_id, match_ref, received_at) VALUES ($1, $2, $3, $4)"
+	result, err := db.Exec(sql, submissionID, tournamentID, ref, now.UTC().Format(webhookTimeLayout))
+	if err != nil {
+		panic(err)
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		panic(err)
+	}
+	return inserted == 1
+}
+
+// releaseScoreSubmission forgets a claimed submission which could not be applied, so that it can be sent again.
+func releaseScoreSubmission(db *sql.DB, submissionID string) {
+	_, err := db.Exec("DELETE FROM score_submission WHERE id = $1", submissionID)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// liveMatchInProgress is a condition on the pool_match rows of the queries, true while the match is played
+// live, its score not being final yet.
+const liveMatchInProgress = `
+	SELECT 1 FROM live_match
+	WHERE live_match.tournament_id = pool_match.tournament_id
+		AND live_match.match_ref = 'pool-' || pool_match.pool_index || '-' || pool_match.id
+		AND live_match.status IN ('in_progress', 'half_time')
+`
+
+// liveRankingMatchInProgress is the same condition on the ranking_match rows.
+const liveRankingMatchInProgress = `
+	SELECT 1 FROM live_match
+	WHERE live_match.tournament_id = ranking_match.tournament_id
+		AND live_match.match_ref = 'ranking-' || ranking_match.key
+		AND live_match.status IN ('in_progress', 'half_time')
+`
+
+func selectLiveMatches(db *sql.DB, tournamentID string) map[string]liveMatch {
+	rows, err := db.Query("SELECT match_ref, status, elapsed_seconds, clock_started_at FROM live_match WHERE tournament_id = $1", tournamentID)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+	live := make(map[string]liveMatch)
+	for rows.Next() {
+		var ref string
+		var elapsedSeconds int
+		var clockStartedAt sql.NullString
+		row := liveMatch{}
+		err2 := rows.Scan(&ref, &row.Status, &elapsedSeconds, &clockStartedAt)
+		if err2 != nil {
+			panic(err2)
+		}
+		row.Elapsed = time.Duration(elapsedSeconds) * time.Second
+		if clockStartedAt.Valid {
+			startedAt, err3 := time.Parse(webhookTimeLayout, clockStartedAt.String)
+			row.ClockStartedAt = NullTime{Time: startedAt, Valid: err3 == nil}
+		}
+		live[ref] = row
+	}
+	return live
+}
+
+func saveLiveMatch(db *sql.DB, tournamentID string, ref string, live liveMatch) {
+	var clockStartedAt sql.NullString
+	if live.ClockStartedAt.Valid {
+		clockStartedAt = sql.NullString{String: live.ClockStartedAt.Time.UTC().Format(webhookTimeLayout), Valid: true}
+	}
+	sql := `
+		INSERT OR REPLACE INTO live_match(tournament_id, match_ref, status, elapsed_seconds, clock_started_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err := db.Exec(sql, tournamentID, ref, live.Status, int(live.Elapsed/time.Second), clockStartedAt)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// finishLiveMatch ends the match when it is played live, stopping its clock.
+func finishLiveMatch(db *sql.DB, tournamentID string, ref string) {
+	sql := `
+		UPDATE live_match SET status = 'finished', clock_started_at = NULL
+		WHERE tournament_id = $1 AND match_ref = $2 AND status IN ('in_progress', 'half_time')
+	`
+	_, err := db.Exec(sql, tournamentID, ref)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// addLiveGoals adds goals to the running score of the match, which starts at 0–0 and never goes below zero.
+func addLiveGoals(db *sql.DB, tournamentID string, ref string, homeTeamGoals int, visitorTeamGoals int) {
+	poolIndex, matchID, key, err := parseMatchRef(ref)
+	if err != nil {
+		panic(err)
+	}
+	set := "home_team_goals = MAX(0, COALESCE(home_team_goals, 0) + $1), visitor_team_goals = MAX(0, COALESCE(visitor_team_goals, 0) + $2)"
+	if key == "" {
+		_, err = db.Exec("UPDATE pool_match SET "+set+", result_status = 'played' WHERE tournament_id = $3 AND pool_index = $4 AND id = $5",
+			homeTeamGoals, visitorTeamGoals, tournamentID, poolIndex, matchID)
+	} else {
+		_, err = db.Exec("UPDATE ranking_match SET "+set+", result_status = 'played' WHERE tournament_id = $3 AND key = $4",
+			homeTeamGoals, visitorTeamGoals, tournamentID, key)
+	}
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func testDB(t *testing.T, statements ...string) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	migrateDB(db)
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func rankingOf(rankings []teamRanking, teamID int) teamRanking {
+	for _, ranking := range rankings {
+		if ranking.ID == teamID {
+			return ranking
+		}
+	}
+	return teamRanking{}
+}
+
+func TestPoolRankingWithForfeitAbandonedAndWithdrawal(t *testing.T) {
+	db := testDB(t,
+		`INSERT INTO tournament(id, name, points_per_win, points_per_draw, points_per_defeat, points_per_goal, date,
+			forfeit_goals, forfeit_penalty_points, forfeit_goals_counted)
+		VALUES ('T', 'U11', 3, 1, 0, 0, '2024-06-15', 3, 1, 0)`,
+		`INSERT INTO pitch(id, name, tournament_id) VALUES (1, 'A', 'T')`,
+		`INSERT INTO pool(tournament_id, pool_index, name) VALUES ('T', 1, 'A')`,
+		`INSERT INTO team(id, tournament_id, pool_index, name) VALUES (1, 'T', 1, 'Lions'), (2, 'T', 1, 'Tigers'), (3, 'T', 1, 'Bears'), (4, 'T', 1, 'Wolves')`,
+		`INSERT INTO pool_match(id, tournament_id, pool_index, scheduled_at, pitch_id, home_team_id, visitor_team_id) VALUES
+			(1, 'T', 1, '2024-06-15 09:00', 1, 1, 2), (2, 'T', 1, '2024-06-15 09:20', 1, 3, 4), (3, 'T', 1, '2024-06-15 09:40', 1, 1, 3),
+			(4, 'T', 1, '2024-06-15 10:00', 1, 2, 4), (5, 'T', 1, '2024-06-15 10:20', 1, 1, 4), (6, 'T', 1, '2024-06-15 10:40', 1, 2, 3)`,
+	)
+	defer db.Close()
+	savePoolMatchScore(db, "T", 1, 1, resultPlayed, 2, 1)
+	savePoolMatchScore(db, "T", 1, 2, resultHomeForfeit, 0, 3)
+	savePoolMatchScore(db, "T", 1, 3, resultAbandoned, 1, 1)
+
+	rankings := selectTournamentPoolRanking(db, "T", 1)
+	if lions := rankingOf(rankings, 1); lions.Played != 1 || lions.Points != 3 || lions.TeamGoals != 2 {
+		t.Errorf("Expected the abandoned match to be left out for Lions, got %+v.", lions)
+	}
+	if bears := rankingOf(rankings, 3); bears.Played != 1 || bears.Defeats != 1 || bears.Points != -1 || bears.OpponentGoals != 0 {
+		t.Errorf("Expected Bears to lose their forfeit with a penalty point and no goals counted, got %+v.", bears)
+	}
+	if wolves := rankingOf(rankings, 4); wolves.Wins != 1 || wolves.Points != 3 || wolves.TeamGoals != 0 {
+		t.Errorf("Expected Wolves to win the forfeit without goals counted, got %+v.", wolves)
+	}
+	for _, row := range selectTournamentFinalRanking(db, "T", nil, false) {
+		if row.TeamID.Int64 == 1 && (row.Played.Int64 != 1 || row.TeamGoals.Int64 != 2) {
+			t.Errorf("Expected the final ranking to leave out the abandoned match of Lions, got %+v.", row)
+		}
+		if row.TeamID.Int64 == 4 && row.Played.Valid {
+			t.Errorf("Expected the final ranking to leave out the forfeit of Wolves, its goals not counting, got %+v.", row)
+		}
+	}
+	if count := countPoolMatchesToBePlayed(db, "T", 1); count != 3 {
+		t.Errorf("Expected the abandoned match to be over and 3 matches to be played, got %d.", count)
+	}
+
+	withdrawTeam(db, "T", 2)
+	rankings = selectTournamentPoolRanking(db, "T", 1)
+	if tigers := rankingOf(rankings, 2); tigers.Played != 3 || tigers.Defeats != 3 || tigers.Points != -2 {
+		t.Errorf("Expected Tigers to forfeit their remaining matches, got %+v.", tigers)
+	}
+	if count := countPoolMatchesToBePlayed(db, "T", 1); count != 1 {
+		t.Errorf("Expected only Lions against Wolves to be played, got %d.", count)
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+)
+
+const (
+	slidePoolsRanking    = "pools_ranking"
+	slideUpcomingMatches = "upcoming_matches"
+	slideLatestResults   = "latest_results"
+	slideBracket         = "bracket"
+	slideSponsor         = "sponsor"
+)
+
+type slideKind struct {
+	Value string
+	Label string
+}
+
+var slideKinds = []slideKind{
+	{slidePoolsRanking, "Classements des poules"},
+	{slideUpcomingMatches, "Prochains matchs par terrain"},
+	{slideLatestResults, "Derniers résultats"},
+	{slideBracket, "Tableau des matchs de classement"},
+	{slideSponsor, "Image partenaire"},
+}
+
+// display is a big screen at the venue, rotating through its slides for one or several tournaments.
+type display struct {
+	ID              int
+	Name            string
+	Locale          string
+	SlideSeconds    int
+	UpcomingMinutes int
+	LatestResults   int
+	TournamentIDs   []string
+}
+
+type displaySlide struct {
+	ID       int
+	Kind     string
+	ImageURL string
+	// Seconds overrides the duration of the slides of the display.
+	Seconds sql.NullInt64
+}
+
+// displayPage is a screen of the rotation: the pool rankings and the bracket are shown once per tournament,
+// the other slides once for all the tournaments of the display.
+type displayPage struct {
+	Slide        displaySlide
+	TournamentID string
+}
+
+// displayMatch is a pool or ranking match as shown on big screens and printouts. The teams of a ranking
+// match not known yet have a zero ID.
+type displayMatch struct {
+	Ref              string
+	TournamentName   string
+	Label            string
+	PoolIndex        int
+	Start            time.Time
+	End              time.Time
+	PitchID          int
+	PitchName        string
+	HomeTeamID       int
+	VisitorTeamID    int
+	HomeTeamName     string
+	VisitorTeamName  string
+	HomeTeamGoals    sql.NullInt64
+	VisitorTeamGoals sql.NullInt64
+	Score            string
+	Played           bool
+	Live             liveMatch
+}
+
+type pitchMatches struct {
+	PitchName string
+	Matches   []displayMatch
+}
+
+// displayRefresh is the page the display loads after the current one.
+type displayRefresh struct {
+	Seconds int
+	URL     string
+}
+
+func validSlideKind(value string) bool {
+	for _, kind := range slideKinds {
+		if kind.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+func displayPages(slides []displaySlide, tournamentIDs []string) []displayPage {
+	pages := make([]displayPage, 0)
+	for _, slide := range slides {
+		if slide.Kind == slidePoolsRanking || slide.Kind == slideBracket {
+			for _, id := range tournamentIDs {
+				pages = append(pages, displayPage{Slide: slide, TournamentID: id})
+			}
+		} else {
+			pages = append(pages, displayPage{Slide: slide})
+		}
+	}
+	return pages
+}
+
+// Seconds is the time the page stays on screen.
+func (d display) Seconds(page displayPage) int {
+	if page.Slide.Seconds.Valid && page.Slide.Seconds.Int64 > 0 {
+		return int(page.Slide.Seconds.Int64)
+	}
+	return d.SlideSeconds
+}
+
+// upcomingMatches lists the matches not played yet which are being played or start within the next minutes,
+// grouped by pitch.
+func upcomingMatches(matches []displayMatch, now time.Time, minutes int) []pitchMatches {
+	until := now.Add(time.Duration(minutes) * time.Minute)
+	selected := make([]displayMatch, 0)
+	for _, match := range matches {
+		if !match.Played && match.End.After(now) && !match.Start.After(until) {
+			selected = append(selected, match)
+		}
+	}
+	sort.SliceStable(selected, func(i, j int) bool { return selected[i].Start.Before(selected[j].Start) })
+	pitches := make([]pitchMatches, 0)
+	for _, match := range selected {
+		found := false
+		for i := range pitches {
+			if pitches[i].PitchName == match.PitchName {
+				pitches[i].Matches = append(pitches[i].Matches, match)
+				found = true
+			}
+		}
+		if !found {
+			pitches = append(pitches, pitchMatches{PitchName: match.PitchName, Matches: []displayMatch{match}})
+		}
+	}
+	sort.SliceStable(pitches, func(i, j int) bool { return pitches[i].PitchName < pitches[j].PitchName })
+	return pitches
+}
+
+// latestResults lists the last played matches, the latest first.
+func latestResults(matches []displayMatch, count int) []displayMatch {
+	played := make([]displayMatch, 0)
+	for _, match := range matches {
+		if match.Played {
+			played = append(played, match)
+		}
+	}
+	sort.SliceStable(played, func(i, j int) bool { return played[i].Start.After(played[j].Start) })
+	if len(played) > count {
+		played = played[:count]
+	}
+	return played
+}
+
+// loadDisplayMatches loads the pool and ranking matches of the tournament, the teams of ranking matches
+// being described until they are known. Matches start at their instant in the time zone of the tournament.
+func loadDisplayMatches(db *sql.DB, tournament tournament, locale string) []displayMatch {
+	duration := tournamentScheduleSettings(tournament).GameDuration
+	start := func(scheduledAt time.Time) time.Time {
+		if at, err := tournament.MatchTime(scheduledAt); err == nil {
+			return at
+		}
+		return scheduledAt
+	}
+	matches := make([]displayMatch, 0)
+	for _, pool := range loadAllPoolsMatches(db, tournament.ID, NullTime{}, NullTime{}) {
+		for _, match := range pool.Matches {
+			matches = append(matches, displayMatch{
+				Ref:              poolMatchRef(match.PoolIndex, match.ID),
+				TournamentName:   tournament.Name,
+				Label:            translate(locale, "pool", pool.PoolName),
+				PoolIndex:        match.PoolIndex,
+				Start:            start(match.ScheduledAt),
+				End:              start(match.ScheduledAt).Add(duration),
+				PitchID:          match.PitchID,
+				PitchName:        match.PitchName,
+				HomeTeamID:       match.HomeTeamID,
+				VisitorTeamID:    match.VisitorTeamID,
+				HomeTeamName:     match.HomeTeamName,
+				VisitorTeamName:  match.VisitorTeamName,
+				HomeTeamGoals:    match.HomeTeamGoals,
+				VisitorTeamGoals: match.VisitorTeamGoals,
+				Score:            match.Score(locale),
+				Played:           match.Played(),
+				Live:             match.Live,
+			})
+		}
+	}
+	for _, match := range tournamentRankingMatches(db, tournament.ID, locale, NullTime{}, NullTime{}) {
+		matches = append(matches, displayMatch{
+			Ref:              rankingMatchRef(match.Key),
+			TournamentName:   tournament.Name,
+			Label:            translate(locale, "match") + " " + match.Key,
+			Start:            start(match.ScheduledAt),
+			End:              start(match.ScheduledAt).Add(duration),
+			PitchID:          match.PitchID,
+			PitchName:        match.PitchName,
+			HomeTeamID:       int(match.HomeTeamID.Int64),
+			VisitorTeamID:    int(match.VisitorTeamID.Int64),
+			HomeTeamName:     match.HomeTeamName.String,
+			VisitorTeamName:  match.VisitorTeamName.String,
+			HomeTeamGoals:    match.HomeTeamGoals,
+			VisitorTeamGoals: match.VisitorTeamGoals,
+			Score:            match.Score(locale),
+			Played:           match.Played(),
+			Live:             match.Live,
+		})
+	}
+	return matches
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDisplayPages(t *testing.T) {
+	slides := []displaySlide{{ID: 1, Kind: slidePoolsRanking}, {ID: 2, Kind: slideUpcomingMatches}, {ID: 3, Kind: slideBracket}}
+	pages := displayPages(slides, []string{"U11", "U13"})
+	expected := []displayPage{
+		{Slide: slides[0], TournamentID: "U11"},
+		{Slide: slides[0], TournamentID: "U13"},
+		{Slide: slides[1]},
+		{Slide: slides[2], TournamentID: "U11"},
+		{Slide: slides[2], TournamentID: "U13"},
+	}
+	if len(pages) != len(expected) {
+		t.Fatalf("Expected %d pages, got %d.", len(expected), len(pages))
+	}
+	for i := range expected {
+		if pages[i].Slide.ID != expected[i].Slide.ID || pages[i].TournamentID != expected[i].TournamentID {
+			t.Errorf("Expected page %d to be %+v, got %+v.", i, expected[i], pages[i])
+		}
+	}
+}
+
+func displayMatchAt(start string, pitchName string, played bool) displayMatch {
+	return displayMatch{
+		Start:     parseTime(start),
+		End:       parseTime(start).Add(20 * time.Minute),
+		PitchName: pitchName,
+		Played:    played,
+	}
+}
+
+func TestUpcomingMatches(t *testing.T) {
+	matches := []displayMatch{
+		displayMatchAt("10:30", "B", false),
+		displayMatchAt("09:50", "A", false),
+		displayMatchAt("10:00", "A", true),
+		displayMatchAt("10:20", "A", false),
+		displayMatchAt("11:00", "A", false),
+		displayMatchAt("09:00", "B", false),
+	}
+	pitches := upcomingMatches(matches, parseTime("10:05"), 30)
+	if len(pitches) != 2 || pitches[0].PitchName != "A" || pitches[1].PitchName != "B" {
+		t.Fatalf("Expected pitches A and B, got %+v.", pitches)
+	}
+	if len(pitches[0].Matches) != 2 || pitches[0].Matches[0].Start != parseTime("09:50") || pitches[0].Matches[1].Start != parseTime("10:20") {
+		t.Errorf("Expected the match being played and the 10:20 match on pitch A, got %+v.", pitches[0].Matches)
+	}
+	if len(pitches[1].Matches) != 1 || pitches[1].Matches[0].Start != parseTime("10:30") {
+		t.Errorf("Expected the 10:30 match on pitch B, got %+v.", pitches[1].Matches)
+	}
+}
+
+func TestUpcomingMatchesInTournamentTimeZone(t *testing.T) {
+	start, err := tournament{TimeZone: "Europe/Paris"}.MatchTime(parseScheduledAt("2024-06-15 10:00"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches := []displayMatch{{Start: start, End: start.Add(20 * time.Minute), PitchName: "A"}}
+	if pitches := upcomingMatches(matches, time.Date(2024, 6, 15, 7, 50, 0, 0, time.UTC), 15); len(pitches) != 1 {
+		t.Errorf("Expected the 10:00 match of Paris to start within 15 minutes at 07:50 UTC, got %+v.", pitches)
+	}
+	if pitches := upcomingMatches(matches, time.Date(2024, 6, 15, 9, 50, 0, 0, time.UTC), 15); len(pitches) != 0 {
+		t.Errorf("Expected the 10:00 match of Paris to be over at 09:50 UTC, got %+v.", pitches)
+	}
+}
+
+func TestLatestResults(t *testing.T) {
+	matches := []displayMatch{
+		displayMatchAt("09:00", "A", true),
+		displayMatchAt("09:40", "A", true),
+		displayMatchAt("10:00", "A", false),
+		displayMatchAt("09:20", "B", true),
+	}
+	results := latestResults(matches, 2)
+	if len(results) != 2 || results[0].Start != parseTime("09:40") || results[1].Start != parseTime("09:20") {
+		t.Errorf("Expected the 09:40 and 09:20 results, got %+v.", results)
+	}
+}
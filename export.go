@@ -0,0 +1,246 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	exportPoolMatches    = "pool-matches"
+	exportRankingMatches = "ranking-matches"
+	exportPoolRankings   = "pool-rankings"
+	exportFinalRanking   = "final-ranking"
+	exportAll            = "all"
+)
+
+// exportTable is a header row followed by data rows. Cells are strings, numbers or nil for empty cells,
+// so that spreadsheets keep numbers as numbers.
+type exportTable struct {
+	Header []string
+	Rows   [][]interface{}
+}
+
+// exportSheet is a sheet of a workbook, its tables being separated by an empty row.
+type exportSheet struct {
+	Name   string
+	Tables []exportTable
+}
+
+var poolMatchesHeader = []string{"Poule", "Date et heure", "Terrain", "Equipe domicile", "Score domicile", "Score visiteur", "Equipe visiteur", "Résultat", "Sets"}
+
+func poolMatchesTable(pools []poolViewModel) exportTable {
+	table := exportTable{Header: poolMatchesHeader}
+	for _, pool := range pools {
+		for _, match := range pool.Matches {
+			table.Rows = append(table.Rows, []interface{}{
+				pool.PoolName,
+				formatScheduledAt(match.ScheduledAt),
+				match.PitchName,
+				match.HomeTeamName,
+				nullIntCell(match.HomeTeamGoals),
+				nullIntCell(match.VisitorTeamGoals),
+				match.VisitorTeamName,
+				resultStatusLabel(match.ResultStatus, match.HomeTeamGoals.Valid),
+				formatSets(match.Sets),
+			})
+		}
+	}
+	return table
+}
+
+func rankingMatchesTable(matches []rankingMatch) exportTable {
+	table := exportTable{Header: []string{
+		"Match", "Date et heure", "Terrain", "Equipe domicile", "Score domicile", "Score visiteur", "Equipe visiteur",
+		"Prolongation domicile", "Prolongation visiteur", "Tirs au but domicile", "Tirs au but visiteur", "Résultat", "Sets",
+		"Vainqueur", "Place du vainqueur", "Place du perdant",
+	}}
+	for _, match := range matches {
+		winner := ""
+		switch {
+		case match.WinnerTeamID.Valid && match.WinnerTeamID == match.HomeTeamID:
+			winner = match.HomeTeamName.String
+		case match.WinnerTeamID.Valid && match.WinnerTeamID == match.VisitorTeamID:
+			winner = match.VisitorTeamName.String
+		}
+		table.Rows = append(table.Rows, []interface{}{
+			match.Key,
+			formatScheduledAt(match.ScheduledAt),
+			match.PitchName,
+			match.HomeTeamName.String,
+			nullIntCell(match.HomeTeamGoals),
+			nullIntCell(match.VisitorTeamGoals),
+			match.VisitorTeamName.String,
+			nullIntCell(match.HomeTeamExtraTimeGoals),
+			nullIntCell(match.VisitorTeamExtraTimeGoals),
+			nullIntCell(match.HomeTeamPenaltyGoals),
+			nullIntCell(match.VisitorTeamPenaltyGoals),
+			resultStatusLabel(match.ResultStatus, match.HomeTeamGoals.Valid),
+			formatSets(match.Sets),
+			winner,
+			nullIntCell(match.WinnerFinalRank),
+			nullIntCell(match.LooserFinalRank),
+		})
+	}
+	return table
+}
+
+var poolRankingHeader = []string{
+	"Rang", "Equipe", "Points", "Joués", "Victoires", "Nuls", "Défaites", "Buts pour", "Buts contre", "Différence",
+	"Rang attaque", "Rang défense", "Ratio sets", "Ratio points", "Points fair-play",
+}
+
+// poolRankingTable lists every column of the ranking of the pools, the pool name being added as a first
+// column when withPool is set.
+func poolRankingTable(rankings []rankingViewModel, withPool bool) exportTable {
+	table := exportTable{Header: poolRankingHeader}
+	if withPool {
+		table.Header = append([]string{"Poule"}, poolRankingHeader...)
+	}
+	for _, ranking := range rankings {
+		for _, team := range ranking.TeamRankings {
+			row := []interface{}{
+				team.Rank, team.Name, team.Points, team.Played, team.Wins, team.Draws, team.Defeats,
+				team.TeamGoals, team.OpponentGoals, team.GoalBalance, team.AttackRank, team.DefenseRank,
+				ratioCell(team.SetRatio), ratioCell(team.PointRatio), team.FairPlayPoints,
+			}
+			if withPool {
+				row = append([]interface{}{ranking.PoolName}, row...)
+			}
+			table.Rows = append(table.Rows, row)
+		}
+	}
+	return table
+}
+
+func finalRankingTable(ranking []tournamentFinalRanking) exportTable {
+	table := exportTable{Header: []string{"Rang", "Equipe", "Buts pour", "Buts contre", "Différence", "Rang attaque", "Rang défense"}}
+	for _, row := range ranking {
+		var name interface{}
+		if row.TeamName.Valid {
+			name = row.TeamName.String
+		}
+		table.Rows = append(table.Rows, []interface{}{
+			row.Rank, name, nullIntCell(row.TeamGoals), nullIntCell(row.OpponentGoals), nullIntCell(row.GoalBalance),
+			nullIntCell(row.AttackRank), nullIntCell(row.DefenseRank),
+		})
+	}
+	return table
+}
+
+// exportWorkbook gathers everything of the tournament: a sheet per pool with its ranking and its matches,
+// then the ranking matches and the final ranking.
+func exportWorkbook(db *sql.DB, tournamentID string) []exportSheet {
+	sheets := make([]exportSheet, 0)
+	for _, pool := range selectTournamentPools(db, tournamentID) {
+		sheets = append(sheets, exportSheet{
+			Name: "Poule " + pool.Name,
+			Tables: []exportTable{
+				poolRankingTable([]rankingViewModel{loadPoolRanking(db, tournamentID, pool)}, false),
+				poolMatchesTable([]poolViewModel{loadPoolMatches(db, pool, NullTime{}, NullTime{})}),
+			},
+		})
+	}
+	sheets = append(sheets,
+		exportSheet{Name: "Matchs de classement", Tables: []exportTable{rankingMatchesTable(tournamentRankingMatches(db, tournamentID, "fr", NullTime{}, NullTime{}))}},
+		exportSheet{Name: "Classement final", Tables: []exportTable{finalRankingTable(loadFinalRanking(db, tournamentID))}},
+	)
+	return sheets
+}
+
+func nullIntCell(value sql.NullInt64) interface{} {
+	if !value.Valid {
+		return nil
+	}
+	return value.Int64
+}
+
+// ratioCell keeps finite ratios as numbers, and writes the ratio of an unbeaten team as formatRatio does,
+// spreadsheets not reading +Inf.
+func ratioCell(value float64) interface{} {
+	if math.IsInf(value, 0) {
+		return formatRatio(value)
+	}
+	return value
+}
+
+// resultStatusLabel is the label of the status of a match, empty until the match has a result.
+func resultStatusLabel(status string, hasResult bool) string {
+	if !hasResult {
+		return ""
+	}
+	for _, s := range resultStatuses {
+		if s.Value == status {
+			return s.Label
+		}
+	}
+	return status
+}
+
+// writeCSV writes the table with a semicolon separator, which spreadsheets expect with French settings,
+// behind a byte order mark so that accents are read as UTF-8.
+func writeCSV(w io.Writer, table exportTable) error {
+	if _, err := io.WriteString(w, "\uFEFF"); err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	writer.Comma = ';'
+	if err := writer.Write(table.Header); err != nil {
+		return err
+	}
+	for _, row := range table.Rows {
+		record := make([]string, len(row))
+		for i, cell := range row {
+			if cell != nil {
+				record[i] = fmt.Sprint(cell)
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeWorkbook(w io.Writer, sheets []exportSheet) error {
+	f := excelize.NewFile()
+	for i, sheet := range sheets {
+		name := sheetName(sheet.Name)
+		if i == 0 {
+			f.SetSheetName("Sheet1", name)
+		} else {
+			f.NewSheet(name)
+		}
+		line := 1
+		for _, table := range sheet.Tables {
+			header := make([]interface{}, len(table.Header))
+			for j, title := range table.Header {
+				header[j] = title
+			}
+			for _, row := range append([][]interface{}{header}, table.Rows...) {
+				row := row
+				if err := f.SetSheetRow(name, fmt.Sprintf("A%d", line), &row); err != nil {
+					return err
+				}
+				line++
+			}
+			line++
+		}
+	}
+	return f.Write(w)
+}
+
+// sheetName removes the characters forbidden in sheet names and shortens the name to 31 characters.
+func sheetName(name string) string {
+	name = strings.NewReplacer(":", " ", "\\", " ", "/", " ", "?", " ", "*", " ", "[", "(", "]", ")").Replace(name)
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	return name
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestWriteCSV(t *testing.T) {
+	table := exportTable{
+		Header: []string{"Equipe", "Buts"},
+		Rows:   [][]interface{}{{"Lions; Tigres", int64(2)}, {"Ours", nil}},
+	}
+	var b bytes.Buffer
+	if err := writeCSV(&b, table); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "\uFEFFEquipe;Buts\n\"Lions; Tigres\";2\nOurs;\n"; b.String() != expected {
+		t.Errorf("Expected %q, got %q.", expected, b.String())
+	}
+}
+
+func TestPoolRankingTable(t *testing.T) {
+	rankings := []rankingViewModel{{PoolName: "A", TeamRankings: []teamRanking{{Rank: 1, Name: "Lions", Points: 6}}}}
+	table := poolRankingTable(rankings, true)
+	if len(table.Header) != len(poolRankingHeader)+1 || table.Header[0] != "Poule" {
+		t.Errorf("Expected the pool as first column, got %v.", table.Header)
+	}
+	if len(table.Rows) != 1 || len(table.Rows[0]) != len(table.Header) || table.Rows[0][0] != "A" || table.Rows[0][2] != "Lions" {
+		t.Errorf("Expected a row per team matching the header, got %v.", table.Rows)
+	}
+	if table := poolRankingTable(rankings, false); len(table.Rows[0]) != len(poolRankingHeader) {
+		t.Errorf("Expected no pool column, got %v.", table.Rows[0])
+	}
+}
+
+func TestPoolRankingTableWithUnbeatenTeam(t *testing.T) {
+	rankings := []rankingViewModel{{PoolName: "A", TeamRankings: []teamRanking{{Rank: 1, Name: "Lions", SetRatio: math.Inf(1), PointRatio: 1.5}}}}
+	row := poolRankingTable(rankings, false).Rows[0]
+	if row[12] != "∞" || row[13] != 1.5 {
+		t.Errorf("Expected an infinite set ratio written as ∞ and a point ratio of 1.5, got %v and %v.", row[12], row[13])
+	}
+	var b bytes.Buffer
+	if err := writeCSV(&b, poolRankingTable(rankings, false)); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(b.String(), "Inf") {
+		t.Errorf("Expected no infinite value in the CSV, got %q.", b.String())
+	}
+}
+
+func TestNullIntCell(t *testing.T) {
+	if cell := nullIntCell(sql.NullInt64{}); cell != nil {
+		t.Errorf("Expected an empty cell, got %v.", cell)
+	}
+	if cell := nullIntCell(nullInt(3)); cell != int64(3) {
+		t.Errorf("Expected 3, got %v.", cell)
+	}
+}
+
+func TestSheetName(t *testing.T) {
+	if name := sheetName("Poule [A/B]"); name != "Poule (A B)" {
+		t.Errorf("Expected forbidden characters to be replaced, got %q.", name)
+	}
+	if name := sheetName("Poule des très très longs noms d'équipes"); len([]rune(name)) != 31 {
+		t.Errorf("Expected 31 characters, got %q.", name)
+	}
+}
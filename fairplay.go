@@ -0,0 +1,180 @@
+package main
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	cardYellow = "yellow"
+	cardRed    = "red"
+)
+
+type cardType struct {
+	Value string
+	Label string
+}
+
+var cardTypes = []cardType{
+	{cardYellow, "Jaune"},
+	{cardRed, "Rouge"},
+}
+
+// card is a yellow or red card shown to a player, or to the team staff when there is no player.
+type card struct {
+	ID         int
+	MatchRef   string
+	TeamID     int
+	TeamName   string
+	PlayerID   sql.NullInt64
+	PlayerName sql.NullString
+	Type       string
+	Minute     sql.NullInt64
+}
+
+type fairPlayRanking struct {
+	Rank        int
+	TeamName    string
+	YellowCards int
+	RedCards    int
+	Points      float64
+}
+
+// suspension is a match a player misses because of a red card or of the accumulation of yellow cards.
+// The match is unknown when the next opponent of the team is not decided yet.
+type suspension struct {
+	PlayerID   int
+	PlayerName string
+	TeamName   string
+	// Reason is the message ID of the cause of the suspension.
+	Reason     string
+	CardMatch  string
+	MatchRef   string
+	MatchStart time.Time
+	MatchTeams string
+}
+
+func validCardType(value string) bool {
+	for _, t := range cardTypes {
+		if t.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+// validCard checks that the card is given to a team of the match, and to one of its players when there is one.
+func validCard(sheet matchSheet, c card) bool {
+	if !validCardType(c.Type) || (c.Minute.Valid && (c.Minute.Int64 < 1 || c.Minute.Int64 > 200)) {
+		return false
+	}
+	for _, team := range sheet.Teams {
+		if team.ID != c.TeamID {
+			continue
+		}
+		if !c.PlayerID.Valid {
+			return true
+		}
+		for _, player := range team.Players {
+			if int64(player.ID) == c.PlayerID.Int64 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// suspensions lists the players suspended for the next match of their team, going through the matches of
+// each team in chronological order. A red card, or every yellowsPerSuspension-th yellow card, suspends the player.
+func suspensions(matches []scheduledMatch, cards []card, yellowsPerSuspension int) []suspension {
+	sorted := make([]scheduledMatch, len(matches))
+	copy(sorted, matches)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+	playerCards := make(map[int][]card)
+	players := make([]int, 0)
+	for _, c := range cards {
+		if !c.PlayerID.Valid {
+			continue
+		}
+		id := int(c.PlayerID.Int64)
+		if _, ok := playerCards[id]; !ok {
+			players = append(players, id)
+		}
+		playerCards[id] = append(playerCards[id], c)
+	}
+	slice := make([]suspension, 0)
+	for _, playerID := range players {
+		teamMatches := teamScheduledMatches(sorted, playerCards[playerID][0].TeamID)
+		yellows := 0
+		for i, match := range teamMatches {
+			reds, matchYellows := 0, 0
+			for _, c := range playerCards[playerID] {
+				if c.MatchRef != match.Ref {
+					continue
+				}
+				if c.Type == cardRed {
+					reds++
+				} else {
+					matchYellows++
+				}
+			}
+			previous := yellows
+			yellows += matchYellows
+			reason := ""
+			switch {
+			case reds > 0:
+				reason = "red_card"
+			case yellowsPerSuspension > 0 && yellows/yellowsPerSuspension > previous/yellowsPerSuspension:
+				reason = "yellow_cards"
+			default:
+				continue
+			}
+			c := playerCards[playerID][0]
+			s := suspension{
+				PlayerID:   playerID,
+				PlayerName: c.PlayerName.String,
+				TeamName:   c.TeamName,
+				Reason:     reason,
+				CardMatch:  match.Ref,
+			}
+			if i+1 < len(teamMatches) {
+				next := teamMatches[i+1]
+				s.MatchRef = next.Ref
+				s.MatchStart = next.Start
+				s.MatchTeams = matchTeamNames(next)
+			}
+			slice = append(slice, s)
+		}
+	}
+	return slice
+}
+
+func teamScheduledMatches(matches []scheduledMatch, teamID int) []scheduledMatch {
+	slice := make([]scheduledMatch, 0)
+	for _, match := range matches {
+		for _, id := range match.TeamIDs {
+			if id == teamID {
+				slice = append(slice, match)
+				break
+			}
+		}
+	}
+	return slice
+}
+
+func matchTeamNames(match scheduledMatch) string {
+	names := make([]string, 0)
+	for _, id := range match.TeamIDs {
+		names = append(names, match.TeamNames[id])
+	}
+	return strings.Join(names, " - ")
+}
+
+// tournamentSuspensions lists the suspensions of the players of the tournament.
+func tournamentSuspensions(db *sql.DB, tournament tournament) []suspension {
+	return suspensions(loadScheduledMatches(db, tournament.ID), selectTournamentCards(db, tournament.ID), tournament.YellowCardsPerSuspension)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestSuspensions(t *testing.T) {
+	matches := []scheduledMatch{
+		poolMatchAt("pool-1-3", 1, "10:00", 1, 1, 3),
+		poolMatchAt("pool-1-1", 1, "09:00", 1, 1, 2),
+		poolMatchAt("pool-1-2", 1, "09:30", 1, 2, 3),
+		rankingMatchAt("ranking-F", "11:00", 1, []int{1, 2}, nil),
+	}
+	cards := []card{
+		{MatchRef: "pool-1-1", TeamID: 1, PlayerID: nullInt(10), Type: cardYellow},
+		{MatchRef: "pool-1-3", TeamID: 1, PlayerID: nullInt(10), Type: cardYellow},
+		{MatchRef: "pool-1-1", TeamID: 2, PlayerID: nullInt(20), Type: cardRed},
+		{MatchRef: "pool-1-1", TeamID: 2, Type: cardRed},
+	}
+	slice := suspensions(matches, cards, 2)
+	if len(slice) != 2 {
+		t.Fatalf("Expected 2 suspensions, got %+v.", slice)
+	}
+	if slice[0].PlayerID != 10 || slice[0].Reason != "yellow_cards" || slice[0].MatchRef != "ranking-F" {
+		t.Errorf("Expected player 10 to miss the final after a second yellow card, got %+v.", slice[0])
+	}
+	if slice[1].PlayerID != 20 || slice[1].Reason != "red_card" || slice[1].MatchRef != "pool-1-2" {
+		t.Errorf("Expected player 20 to miss the next pool match after a red card, got %+v.", slice[1])
+	}
+	if len(suspensions(matches, cards[:2], 0)) != 0 {
+		t.Errorf("Expected no suspension for yellow cards when disabled.")
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"database/sql"
+	"sort"
+)
+
+const (
+	eliminatedRankingCompared = "compared"
+	eliminatedRankingShared   = "shared"
+)
+
+type eliminatedRanking struct {
+	Value string
+	Label string
+}
+
+// eliminatedRankings are the ways the teams eliminated in pools are placed after the teams of the ranking
+// matches, pool rank by pool rank.
+var eliminatedRankings = []eliminatedRanking{
+	{eliminatedRankingCompared, "Comparer les équipes d'un même rang de poule"},
+	{eliminatedRankingShared, "Places partagées entre les équipes d'un même rang de poule"},
+}
+
+func validEliminatedRanking(value string) bool {
+	for _, r := range eliminatedRankings {
+		if r.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+// eliminatedPlace is the place in the final ranking of a team eliminated in its pool.
+type eliminatedPlace struct {
+	TeamID int
+	Place  int
+	Shared bool
+}
+
+// placeEliminated numbers from first on the places of the teams eliminated in pools, given by pool rank and
+// ordered across pools. Teams of a pool rank share their place when places are shared, otherwise only when
+// they are level on every criterion the comparison uses.
+func placeEliminated(first int, byRank [][]crossPoolRanking, shared bool, fairPlayTieBreak bool) []eliminatedPlace {
+	places := make([]eliminatedPlace, 0)
+	counts := make(map[int]int)
+	place := first
+	for _, teams := range byRank {
+		for i, team := range teams {
+			if i > 0 && !shared && !levelAcrossPools(teams[i-1], team, fairPlayTieBreak) {
+				place = first + i
+			}
+			places = append(places, eliminatedPlace{TeamID: team.Team.ID, Place: place})
+			counts[place]++
+		}
+		first += len(teams)
+		place = first
+	}
+	for i := range places {
+		places[i].Shared = counts[places[i].Place] > 1
+	}
+	return places
+}
+
+func levelAcrossPools(a crossPoolRanking, b crossPoolRanking, fairPlayTieBreak bool) bool {
+	if a.criteria() != b.criteria() {
+		return false
+	}
+	return !fairPlayTieBreak || a.Team.FairPlayPoints == b.Team.FairPlayPoints
+}
+
+// placeBracketLosers places the losers of the ranking matches deciding no final rank for them and sending
+// them to no other match. Losers of a same round of a bracket share the next place left free by the
+// assigned ones, the brackets being taken in order and their rounds from the last one. It returns the
+// places with the assigned ones updated.
+func placeBracketLosers(matches []rankingMatch, assigned map[int]bool) []eliminatedPlace {
+	looserPlays := make(map[string]bool)
+	for _, match := range matches {
+		if match.HomeTeamSourceRankingMatch.Valid && !match.HomeTeamSourceRankingMatchWinner.Bool {
+			looserPlays[match.HomeTeamSourceRankingMatch.String] = true
+		}
+		if match.VisitorTeamSourceRankingMatch.Valid && !match.VisitorTeamSourceRankingMatchWinner.Bool {
+			looserPlays[match.VisitorTeamSourceRankingMatch.String] = true
+		}
+	}
+	places := make([]eliminatedPlace, 0)
+	for _, tree := range rankingBrackets(matches) {
+		for round := []bracketNode{tree.Root}; len(round) > 0; {
+			losers := make([]int, 0)
+			next := make([]bracketNode, 0)
+			for _, node := range round {
+				match := node.Match
+				if match.LooserTeamID.Valid && !match.LooserFinalRank.Valid && !looserPlays[match.Key] {
+					losers = append(losers, int(match.LooserTeamID.Int64))
+				}
+				next = append(next, node.Children...)
+			}
+			if len(losers) > 0 {
+				place := nextFreePlace(assigned, len(losers))
+				for _, teamID := range losers {
+					places = append(places, eliminatedPlace{TeamID: teamID, Place: place, Shared: len(losers) > 1})
+				}
+				for i := 0; i < len(losers); i++ {
+					assigned[place+i] = true
+				}
+			}
+			round = next
+		}
+	}
+	return places
+}
+
+// nextFreePlace returns the first place from which count places are not assigned.
+func nextFreePlace(assigned map[int]bool, count int) int {
+	for place := 1; ; place++ {
+		free := true
+		for i := 0; i < count && free; i++ {
+			free = !assigned[place+i]
+		}
+		if free {
+			return place
+		}
+	}
+}
+
+// loadFinalRanking returns the final ranking: the places decided by the ranking matches and the losers of
+// the other ranking matches then, once every pool is over, the teams eliminated in pools. Attack and
+// defense ranks are normalised the way the tournament compares teams of pools of different sizes.
+func loadFinalRanking(db *sql.DB, tournamentID string) []tournamentFinalRanking {
+	t := selectTournament(db, tournamentID)
+	pools := selectTournamentPools(db, tournamentID)
+	rankings := make(map[int][]teamRanking)
+	over := true
+	for _, pool := range pools {
+		rankings[pool.Index] = rankPool(db, tournamentID, pool.Index)
+		over = over && countPoolMatchesToBePlayed(db, tournamentID, pool.Index) == 0
+	}
+	excluded := make([]int, 0)
+	if t.Normalisation == normalisationWeakest {
+		excluded = weakestTeamIDs(rankings)
+	}
+	matches := selectTournamentRankingMatches(db, tournamentID, NullTime{}, NullTime{})
+	inBracket := make(map[int]bool)
+	for _, match := range matches {
+		if match.HomeTeamID.Valid {
+			inBracket[int(match.HomeTeamID.Int64)] = true
+		}
+		if match.VisitorTeamID.Valid {
+			inBracket[int(match.VisitorTeamID.Int64)] = true
+		}
+	}
+	ranking := make([]tournamentFinalRanking, 0)
+	assigned := make(map[int]bool)
+	unranked := make(map[int]tournamentFinalRanking)
+	eliminated := make(map[int]tournamentFinalRanking)
+	for _, row := range selectTournamentFinalRanking(db, tournamentID, excluded, t.Normalisation == normalisationAverage) {
+		switch {
+		case row.Rank != 0:
+			ranking = append(ranking, row)
+			assigned[row.Rank] = true
+		case !row.TeamID.Valid:
+		case inBracket[int(row.TeamID.Int64)]:
+			unranked[int(row.TeamID.Int64)] = row
+		default:
+			eliminated[int(row.TeamID.Int64)] = row
+		}
+	}
+	for _, place := range placeBracketLosers(matches, assigned) {
+		if row, ok := unranked[place.TeamID]; ok {
+			row.Rank, row.Shared = place.Place, place.Shared
+			ranking = append(ranking, row)
+		}
+	}
+	sort.SliceStable(ranking, func(i, j int) bool {
+		return ranking[i].Rank < ranking[j].Rank
+	})
+	if !over || len(eliminated) == 0 {
+		return ranking
+	}
+	largestPool := 0
+	for _, pool := range rankings {
+		if len(pool) > largestPool {
+			largestPool = len(pool)
+		}
+	}
+	byRank := make([][]crossPoolRanking, 0)
+	for rank := 1; rank <= largestPool; rank++ {
+		teams := make([]crossPoolRanking, 0)
+		for _, team := range rankAcrossPools(db, t, rank) {
+			if _, ok := eliminated[team.Team.ID]; ok {
+				teams = append(teams, team)
+			}
+		}
+		byRank = append(byRank, teams)
+	}
+	first := 1
+	for place := range assigned {
+		if place >= first {
+			first = place + 1
+		}
+	}
+	for _, place := range placeEliminated(first, byRank, t.EliminatedRanking == eliminatedRankingShared, t.FairPlayTieBreak) {
+		row := eliminated[place.TeamID]
+		row.Rank, row.Shared = place.Place, place.Shared
+		ranking = append(ranking, row)
+	}
+	sort.SliceStable(ranking, func(i, j int) bool {
+		return ranking[i].Rank < ranking[j].Rank
+	})
+	return ranking
+}
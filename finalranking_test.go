@@ -0,0 +1,75 @@
+package main
+
+import "testing"
+
+func TestPlaceEliminated(t *testing.T) {
+	byRank := [][]crossPoolRanking{
+		{
+			{Team: teamRanking{ID: 1, Points: 4, GoalBalance: 1, TeamGoals: 3}},
+			{Team: teamRanking{ID: 2, Points: 4, GoalBalance: 1, TeamGoals: 3}},
+			{Team: teamRanking{ID: 3, Points: 3, GoalBalance: 2, TeamGoals: 3}},
+		},
+		{
+			{Team: teamRanking{ID: 4, Points: 1}},
+		},
+	}
+	expected := []eliminatedPlace{{1, 9, true}, {2, 9, true}, {3, 11, false}, {4, 12, false}}
+	places := placeEliminated(9, byRank, false, false)
+	for i, place := range expected {
+		if places[i] != place {
+			t.Errorf("Expected %+v, got %+v.", place, places[i])
+		}
+	}
+	expected = []eliminatedPlace{{1, 9, true}, {2, 9, true}, {3, 9, true}, {4, 12, false}}
+	places = placeEliminated(9, byRank, true, false)
+	for i, place := range expected {
+		if places[i] != place {
+			t.Errorf("Expected %+v with shared places, got %+v.", place, places[i])
+		}
+	}
+	byRank[0][1].Team.FairPlayPoints = 2
+	if places = placeEliminated(9, byRank, false, true); places[1].Place != 10 || places[0].Shared {
+		t.Errorf("Expected the fair-play points to break the tie, got %+v.", places)
+	}
+}
+
+func TestPlaceBracketLosers(t *testing.T) {
+	lost := func(match rankingMatch, looserTeamID int64) rankingMatch {
+		match.LooserTeamID = nullInt(looserTeamID)
+		return match
+	}
+	matches := []rankingMatch{
+		lost(bracketMatch("QF1", "09:00", "", "", false, 0, 0), 11),
+		lost(bracketMatch("QF2", "09:00", "", "", false, 0, 0), 12),
+		lost(bracketMatch("QF3", "09:00", "", "", false, 0, 0), 13),
+		lost(bracketMatch("QF4", "09:00", "", "", false, 0, 0), 14),
+		lost(bracketMatch("SF1", "10:00", "QF1", "QF2", true, 0, 0), 21),
+		lost(bracketMatch("SF2", "10:00", "QF3", "QF4", true, 0, 0), 22),
+		bracketMatch("P5", "11:00", "QF1", "QF2", false, 5, 6),
+		bracketMatch("F", "11:00", "SF1", "SF2", true, 1, 2),
+	}
+	assigned := map[int]bool{1: true, 2: true, 5: true, 6: true}
+	expected := []eliminatedPlace{{21, 3, true}, {22, 3, true}, {13, 7, true}, {14, 7, true}}
+	places := placeBracketLosers(matches, assigned)
+	if len(places) != len(expected) {
+		t.Fatalf("Expected %+v, got %+v.", expected, places)
+	}
+	for i, place := range expected {
+		if places[i] != place {
+			t.Errorf("Expected %+v, got %+v.", place, places[i])
+		}
+	}
+	if !assigned[3] || !assigned[4] || !assigned[7] || !assigned[8] {
+		t.Errorf("Expected the places of the losers to be assigned, got %v.", assigned)
+	}
+}
+
+func TestNextFreePlace(t *testing.T) {
+	assigned := map[int]bool{1: true, 2: true, 4: true}
+	if place := nextFreePlace(assigned, 1); place != 3 {
+		t.Errorf("Expected 3, got %d.", place)
+	}
+	if place := nextFreePlace(assigned, 2); place != 5 {
+		t.Errorf("Expected 5, got %d.", place)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"database/sql"
+)
+
+// resultScore returns the score to record for a result status: forfeits are recorded with the score
+// awarded by the tournament rules, other statuses keep the entered score.
+func resultScore(status string, forfeitGoals int, homeTeamGoals int, visitorTeamGoals int) (int, int) {
+	switch status {
+	case resultHomeForfeit:
+		return 0, forfeitGoals
+	case resultVisitorForfeit:
+		return forfeitGoals, 0
+	case resultDoubleForfeit:
+		return 0, 0
+	default:
+		return homeTeamGoals, visitorTeamGoals
+	}
+}
+
+func validResultStatus(status string, statuses []resultStatus) bool {
+	for _, s := range statuses {
+		if s.Value == status {
+			return true
+		}
+	}
+	return false
+}
+
+// completePool sends the teams of the pool to their ranking matches once all its matches are over, and the
+// best teams at a rank across pools once the last pool is over.
+func completePool(db *sql.DB, tournamentID string, poolIndex int) {
+	if countPoolMatchesToBePlayed(db, tournamentID, poolIndex) > 0 {
+		return
+	}
+	for _, teamRank := range rankPool(db, tournamentID, poolIndex) {
+		updateRankingMatchFromPoolRank(db, tournamentID, poolIndex, teamRank.Rank, teamRank.ID)
+	}
+	completeBestRankedSlots(db, tournamentID)
+	applyWithdrawals(db, tournamentID)
+}
+
+// recordRankingMatchResult saves the result of a ranking match and sends its winner and loser to the next matches.
+func recordRankingMatchResult(db *sql.DB, tournamentID string, key string, status string, score rankingMatchScore) {
+	homeTeamID, visitorTeamID := selectRankingMatchTeamIDs(db, tournamentID, key)
+	winnerTeamID, looserTeamID := visitorTeamID, homeTeamID
+	if status == resultVisitorForfeit || (status != resultHomeForfeit && score.HomeTeamWins()) {
+		winnerTeamID, looserTeamID = homeTeamID, visitorTeamID
+	}
+	saveRankingMatchScore(db, tournamentID, key, status, score, winnerTeamID, looserTeamID)
+	updateRankingMatchFromSourceRankingMatch(db, tournamentID, key, winnerTeamID, looserTeamID)
+}
+
+// withdrawTeam forfeits all the remaining matches of the team, then lets its opponents through the bracket.
+func withdrawTeam(db *sql.DB, tournamentID string, teamID int) {
+	tournament := selectTournament(db, tournamentID)
+	updateTeamWithdrawn(db, tournamentID, teamID)
+	withdrawn := withdrawnTeamIDs(db, tournamentID)
+	pools := make(map[int]bool)
+	for _, match := range selectAllTournamentPoolMatches(db, tournamentID) {
+		if match.HomeTeamGoals.Valid || (match.HomeTeamID != teamID && match.VisitorTeamID != teamID) {
+			continue
+		}
+		status := resultHomeForfeit
+		if withdrawn[match.HomeTeamID] && withdrawn[match.VisitorTeamID] {
+			status = resultDoubleForfeit
+		} else if withdrawn[match.VisitorTeamID] {
+			status = resultVisitorForfeit
+		}
+		homeTeamGoals, visitorTeamGoals := resultScore(status, tournament.ForfeitGoals, 0, 0)
+		savePoolMatchScore(db, tournamentID, match.PoolIndex, match.ID, status, homeTeamGoals, visitorTeamGoals)
+		pools[match.PoolIndex] = true
+	}
+	for poolIndex := range pools {
+		completePool(db, tournamentID, poolIndex)
+	}
+	applyWithdrawals(db, tournamentID)
+}
+
+// applyWithdrawals forfeits the ranking matches of withdrawn teams as soon as both teams are known.
+// Each forfeit may bring a withdrawn team to another match, so it goes on until nothing changes. A match
+// between two withdrawn teams has no winner to send on, it is left for the organisers to decide.
+func applyWithdrawals(db *sql.DB, tournamentID string) {
+	withdrawn := withdrawnTeamIDs(db, tournamentID)
+	if len(withdrawn) == 0 {
+		return
+	}
+	tournament := selectTournament(db, tournamentID)
+	for changed := true; changed; {
+		changed = false
+		for _, match := range selectTournamentRankingMatches(db, tournamentID, NullTime{}, NullTime{}) {
+			known := match.HomeTeamID.Int64 != 0 && match.VisitorTeamID.Int64 != 0
+			if match.HomeTeamGoals.Valid || !known {
+				continue
+			}
+			var status string
+			switch {
+			case withdrawn[int(match.HomeTeamID.Int64)] && withdrawn[int(match.VisitorTeamID.Int64)]:
+				continue
+			case withdrawn[int(match.HomeTeamID.Int64)]:
+				status = resultHomeForfeit
+			case withdrawn[int(match.VisitorTeamID.Int64)]:
+				status = resultVisitorForfeit
+			default:
+				continue
+			}
+			homeTeamGoals, visitorTeamGoals := resultScore(status, tournament.ForfeitGoals, 0, 0)
+			recordRankingMatchResult(db, tournamentID, match.Key, status, rankingMatchScore{HomeTeamGoals: homeTeamGoals, VisitorTeamGoals: visitorTeamGoals})
+			changed = true
+		}
+	}
+}
+
+func withdrawnTeamIDs(db *sql.DB, tournamentID string) map[int]bool {
+	withdrawn := make(map[int]bool)
+	for _, team := range selectTournamentTeams(db, tournamentID) {
+		if team.Withdrawn {
+			withdrawn[team.ID] = true
+		}
+	}
+	return withdrawn
+}
@@ -0,0 +1,60 @@
+package main
+
+import "testing"
+
+func TestApplyWithdrawalsBetweenTwoWithdrawnTeams(t *testing.T) {
+	db := testDB(t,
+		`INSERT INTO tournament(id, name, points_per_win, points_per_draw, points_per_defeat, points_per_goal, date, forfeit_goals)
+		VALUES ('T', 'U11', 3, 1, 0, 0, '2024-06-15', 3)`,
+		`INSERT INTO pitch(id, name, tournament_id) VALUES (1, 'A', 'T')`,
+		`INSERT INTO team(id, tournament_id, pool_index, name) VALUES (1, 'T', 1, 'Lions'), (2, 'T', 1, 'Tigers'), (3, 'T', 1, 'Bears'), (4, 'T', 1, 'Wolves')`,
+		`INSERT INTO ranking_match(key, tournament_id, scheduled_at, pitch_id, home_team_id, visitor_team_id) VALUES
+			('SF1', 'T', '2024-06-15 10:00', 1, 1, 2), ('SF2', 'T', '2024-06-15 10:00', 1, 3, 4)`,
+		`UPDATE team SET withdrawn = 1 WHERE id IN (1, 2, 4)`,
+	)
+	defer db.Close()
+	applyWithdrawals(db, "T")
+	for _, match := range selectTournamentRankingMatches(db, "T", NullTime{}, NullTime{}) {
+		switch match.Key {
+		case "SF1":
+			if match.HomeTeamGoals.Valid || match.WinnerTeamID.Valid {
+				t.Errorf("Expected no winner between two withdrawn teams, got %+v.", match)
+			}
+		case "SF2":
+			if match.ResultStatus != resultVisitorForfeit || match.WinnerTeamID.Int64 != 3 {
+				t.Errorf("Expected Bears to win by forfeit, got %+v.", match)
+			}
+		}
+	}
+}
+
+func TestResultScoreOfForfeits(t *testing.T) {
+	cases := []struct {
+		status          string
+		expectedHome    int
+		expectedVisitor int
+	}{
+		{resultPlayed, 2, 1},
+		{resultHomeForfeit, 0, 3},
+		{resultVisitorForfeit, 3, 0},
+		{resultDoubleForfeit, 0, 0},
+		{resultAwarded, 2, 1},
+	}
+	for _, c := range cases {
+		home, visitor := resultScore(c.status, 3, 2, 1)
+		if home != c.expectedHome || visitor != c.expectedVisitor {
+			t.Errorf("Expected %d-%d for %s, got %d-%d.", c.expectedHome, c.expectedVisitor, c.status, home, visitor)
+		}
+	}
+}
+
+func TestRankingMatchesOnlyAcceptDecisiveResults(t *testing.T) {
+	for _, status := range []string{resultDoubleForfeit, resultAbandoned, resultAwarded} {
+		if validResultStatus(status, rankingMatchResultStatuses) {
+			t.Errorf("Expected %s to be rejected for ranking matches.", status)
+		}
+	}
+	if !validResultStatus(resultVisitorForfeit, rankingMatchResultStatuses) {
+		t.Errorf("Expected %s to be accepted for ranking matches.", resultVisitorForfeit)
+	}
+}
@@ -0,0 +1,614 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/thoas/go-funk"
+)
+
+const defaultLocale = "fr"
+
+type locale struct {
+	Code string
+	Name string
+}
+
+// localeLink is a locale of the language switcher, linking to the current page in that locale.
+type localeLink struct {
+	locale
+	URL string
+}
+
+var locales = []locale{
+	{"fr", "Français"},
+	{"en", "English"},
+	{"de", "Deutsch"},
+	{"es", "Español"},
+}
+
+// messages holds the catalog of every locale, keyed by message ID. Messages may contain fmt verbs. The
+// catalogs cover what visitors see: the public pages, the displays and the calendars. The admin pages,
+// with the schedule issues and the publish checklist, are for the organisers and stay in French.
+var messages = map[string]map[string]string{
+	"fr": {
+		"tournaments":          "Tournois",
+		"tournament":           "Tournoi",
+		"name":                 "Nom",
+		"pools":                "Poules",
+		"ranking":              "Classement",
+		"rankings":             "Classements",
+		"matches":              "Rencontres",
+		"all_matches":          "Toutes les rencontres",
+		"all_pools_matches":    "Rencontres toutes poules",
+		"pool_matches":         "Rencontres poule %s",
+		"all_pools_ranking":    "Classement toutes poules",
+		"pool_ranking":         "Classement poule %s",
+		"ranking_matches":      "Matchs de classement",
+		"final_ranking":        "Classement final",
+		"tournament_matches":   "Rencontres %s",
+		"tournament_ranking":   "Classement %s",
+		"tournament_final":     "%s classement final",
+		"pool":                 "Poule %s",
+		"pitch":                "Terrain %s",
+		"time":                 "Heure",
+		"team":                 "Equipe",
+		"score":                "Score",
+		"match":                "Match",
+		"points_short":         "Pts",
+		"played_short":         "J",
+		"wins_short":           "V",
+		"draws_short":          "N",
+		"defeats_short":        "D",
+		"goals_for_short":      "Bp",
+		"goals_against_short":  "Bc",
+		"goal_balance_short":   "Diff",
+		"attack_rank":          "# Attaque",
+		"defense_rank":         "# Défense",
+		"pool_rank_team":       "%[1]s poule %[2]s",
+		"winner_of_match":      "Gagnant match %s",
+		"loser_of_match":       "Perdant match %s",
+		"after_extra_time":     "a.p.",
+		"penalty_shoot_out":    "t.a.b.",
+		"home_forfeit":         "forfait",
+		"visitor_forfeit":      "forfait",
+		"double_forfeit":       "double forfait",
+		"abandoned":            "arrêté",
+		"awarded":              "sur tapis vert",
+		"sets_won_short":       "Sets G",
+		"sets_lost_short":      "Sets P",
+		"set_ratio":            "Ratio sets",
+		"point_ratio":          "Ratio points",
+		"points_for_short":     "Pts+",
+		"points_against_short": "Pts-",
+		"point_balance_short":  "Diff",
+		"top_scorers":          "Meilleurs buteurs",
+		"all_top_scorers":      "Meilleurs buteurs tous tournois",
+		"player":               "Joueur",
+		"number_short":         "N°",
+		"goals":                "Buts",
+		"fair_play":            "Fair-play",
+		"yellow_cards_short":   "CJ",
+		"red_cards_short":      "CR",
+		"suspensions":          "Suspensions",
+		"reason":               "Motif",
+		"suspended_for":        "Match de suspension",
+		"next_match":           "Prochain match",
+		"red_card":             "Carton rouge",
+		"yellow_cards":         "Cumul de cartons jaunes",
+		"bracket_path":         "Parcours en phase finale",
+		"won":                  "Victoire",
+		"lost":                 "Défaite",
+		"if_win":               "En cas de victoire : %s",
+		"if_lose":              "En cas de défaite : %s",
+		"final_position":       "%s du classement final",
+		"choose_team":          "Choisir une équipe",
+		"bracket":              "Tableau",
+		"bracket_places":       "Places %d à %d",
+		"upcoming_matches":     "Prochains matchs",
+		"latest_results":       "Derniers résultats",
+		"no_matches":           "Aucun match",
+		"match_sheet":          "Feuille de match",
+		"referee":              "Arbitre",
+		"signatures":           "Signatures",
+		"captain":              "Capitaine",
+		"cards":                "Cartons",
+		"certificate":          "Diplôme",
+		"opponent":             "Adversaire : %s",
+		"result":               "Résultat : %s",
+		"calendar":             "Calendrier",
+		"live":                 "LIVE",
+		"half_time":            "Mi-temps",
+		"pool_scenarios":       "Scénarios poule %s",
+		"scenarios":            "Scénarios",
+		"if_pool_ended_now":    "Si la poule s'arrêtait maintenant",
+		"provisional":          "provisoirement %s",
+		"remaining_matches":    "Matchs restants",
+		"possible_ranks":       "Places possibles",
+		"qualifying_places":    "Les %d premiers accèdent au tableau final",
+		"qualified":            "Qualifié",
+		"eliminated":           "Éliminé",
+		"still_open":           "Dépend des autres résultats",
+		"win_against":          "victoire contre %s",
+		"draw_against":         "nul contre %s",
+		"defeat_against":       "défaite contre %s",
+		"with_goal_margins":    "Avec les écarts de buts",
+		"results_only":         "Résultats seulement",
+		"too_many_scenarios":   "Trop de matchs restent à jouer pour calculer les scénarios.",
+		"results_only_note":    "Sans les écarts de buts, les équipes à égalité de points peuvent finir dans un sens ou dans l'autre.",
+		"margins_limit_note":   "Écarts de buts envisagés jusqu'à %d buts : un écart plus large peut encore changer ces scénarios.",
+		"best_rank_team":       "Meilleur %[2]s n°%[1]d",
+		"best_ranked":          "Classement des %s de poule",
+		"best_ranked_note":     "Sans les résultats contre les équipes classées après la %s place.",
+		"best_ranked_average":  "Moyennes par match entre parenthèses, utilisées pour le classement.",
+		"shared_place":         "ex æquo",
+	},
+	"en": {
+		"tournaments":          "Tournaments",
+		"tournament":           "Tournament",
+		"name":                 "Name",
+		"pools":                "Pools",
+		"ranking":              "Ranking",
+		"rankings":             "Rankings",
+		"matches":              "Matches",
+		"all_matches":          "All matches",
+		"all_pools_matches":    "All pools matches",
+		"pool_matches":         "Pool %s matches",
+		"all_pools_ranking":    "All pools ranking",
+		"pool_ranking":         "Pool %s ranking",
+		"ranking_matches":      "Ranking matches",
+		"final_ranking":        "Final ranking",
+		"tournament_matches":   "%s matches",
+		"tournament_ranking":   "%s ranking",
+		"tournament_final":     "%s final ranking",
+		"pool":                 "Pool %s",
+		"pitch":                "Pitch %s",
+		"time":                 "Time",
+		"team":                 "Team",
+		"score":                "Score",
+		"match":                "Match",
+		"points_short":         "Pts",
+		"played_short":         "P",
+		"wins_short":           "W",
+		"draws_short":          "D",
+		"defeats_short":        "L",
+		"goals_for_short":      "GF",
+		"goals_against_short":  "GA",
+		"goal_balance_short":   "GD",
+		"attack_rank":          "# Attack",
+		"defense_rank":         "# Defense",
+		"pool_rank_team":       "%[1]s in pool %[2]s",
+		"winner_of_match":      "Winner match %s",
+		"loser_of_match":       "Loser match %s",
+		"after_extra_time":     "a.e.t.",
+		"penalty_shoot_out":    "pens",
+		"home_forfeit":         "forfeit",
+		"visitor_forfeit":      "forfeit",
+		"double_forfeit":       "double forfeit",
+		"abandoned":            "abandoned",
+		"awarded":              "awarded",
+		"sets_won_short":       "SW",
+		"sets_lost_short":      "SL",
+		"set_ratio":            "Set ratio",
+		"point_ratio":          "Point ratio",
+		"points_for_short":     "PF",
+		"points_against_short": "PA",
+		"point_balance_short":  "Diff",
+		"top_scorers":          "Top scorers",
+		"all_top_scorers":      "Top scorers of all tournaments",
+		"player":               "Player",
+		"number_short":         "No.",
+		"goals":                "Goals",
+		"fair_play":            "Fair play",
+		"yellow_cards_short":   "YC",
+		"red_cards_short":      "RC",
+		"suspensions":          "Suspensions",
+		"reason":               "Reason",
+		"suspended_for":        "Suspended for",
+		"next_match":           "Next match",
+		"red_card":             "Red card",
+		"yellow_cards":         "Yellow card accumulation",
+		"bracket_path":         "Path through the bracket",
+		"won":                  "Won",
+		"lost":                 "Lost",
+		"if_win":               "If they win: %s",
+		"if_lose":              "If they lose: %s",
+		"final_position":       "%s place",
+		"choose_team":          "Choose a team",
+		"bracket":              "Bracket",
+		"bracket_places":       "Places %d to %d",
+		"upcoming_matches":     "Upcoming matches",
+		"latest_results":       "Latest results",
+		"no_matches":           "No match",
+		"match_sheet":          "Match sheet",
+		"referee":              "Referee",
+		"signatures":           "Signatures",
+		"captain":              "Captain",
+		"cards":                "Cards",
+		"certificate":          "Certificate",
+		"opponent":             "Opponent: %s",
+		"result":               "Result: %s",
+		"calendar":             "Calendar",
+		"live":                 "LIVE",
+		"half_time":            "Half-time",
+		"pool_scenarios":       "Pool %s scenarios",
+		"scenarios":            "Scenarios",
+		"if_pool_ended_now":    "If the pool ended now",
+		"provisional":          "currently %s",
+		"remaining_matches":    "Remaining matches",
+		"possible_ranks":       "Possible places",
+		"qualifying_places":    "The top %d go to the main bracket",
+		"qualified":            "Qualified",
+		"eliminated":           "Eliminated",
+		"still_open":           "Depends on other results",
+		"win_against":          "win against %s",
+		"draw_against":         "draw against %s",
+		"defeat_against":       "defeat against %s",
+		"with_goal_margins":    "With goal margins",
+		"results_only":         "Results only",
+		"too_many_scenarios":   "Too many matches remain to compute the scenarios.",
+		"results_only_note":    "Without goal margins, teams level on points may finish either way round.",
+		"margins_limit_note":   "Goal margins considered up to %d goals: a wider margin may still change these scenarios.",
+		"best_rank_team":       "Best %[2]s-placed team #%[1]d",
+		"best_ranked":          "Ranking of the %s-placed teams",
+		"best_ranked_note":     "Results against the teams ranked below %s are left out.",
+		"best_ranked_average":  "Averages per match in brackets, used for the ranking.",
+		"shared_place":         "tied",
+	},
+	"de": {
+		"tournaments":          "Turniere",
+		"tournament":           "Turnier",
+		"name":                 "Name",
+		"pools":                "Gruppen",
+		"ranking":              "Tabelle",
+		"rankings":             "Tabellen",
+		"matches":              "Spiele",
+		"all_matches":          "Alle Spiele",
+		"all_pools_matches":    "Spiele aller Gruppen",
+		"pool_matches":         "Spiele Gruppe %s",
+		"all_pools_ranking":    "Tabellen aller Gruppen",
+		"pool_ranking":         "Tabelle Gruppe %s",
+		"ranking_matches":      "Platzierungsspiele",
+		"final_ranking":        "Endstand",
+		"tournament_matches":   "Spiele %s",
+		"tournament_ranking":   "Tabelle %s",
+		"tournament_final":     "%s Endstand",
+		"pool":                 "Gruppe %s",
+		"pitch":                "Platz %s",
+		"time":                 "Uhrzeit",
+		"team":                 "Mannschaft",
+		"score":                "Ergebnis",
+		"match":                "Spiel",
+		"points_short":         "Pkt",
+		"played_short":         "Sp",
+		"wins_short":           "S",
+		"draws_short":          "U",
+		"defeats_short":        "N",
+		"goals_for_short":      "T",
+		"goals_against_short":  "GT",
+		"goal_balance_short":   "Diff",
+		"attack_rank":          "# Angriff",
+		"defense_rank":         "# Abwehr",
+		"pool_rank_team":       "%[1]s Gruppe %[2]s",
+		"winner_of_match":      "Sieger Spiel %s",
+		"loser_of_match":       "Verlierer Spiel %s",
+		"after_extra_time":     "n.V.",
+		"penalty_shoot_out":    "i.E.",
+		"home_forfeit":         "kampflos",
+		"visitor_forfeit":      "kampflos",
+		"double_forfeit":       "beidseitig kampflos",
+		"abandoned":            "abgebrochen",
+		"awarded":              "am grünen Tisch",
+		"sets_won_short":       "Sätze +",
+		"sets_lost_short":      "Sätze -",
+		"set_ratio":            "Satzquotient",
+		"point_ratio":          "Ballquotient",
+		"points_for_short":     "Pkt +",
+		"points_against_short": "Pkt -",
+		"point_balance_short":  "Diff",
+		"top_scorers":          "Torschützenliste",
+		"all_top_scorers":      "Torschützenliste aller Turniere",
+		"player":               "Spieler",
+		"number_short":         "Nr.",
+		"goals":                "Tore",
+		"fair_play":            "Fairplay",
+		"yellow_cards_short":   "GK",
+		"red_cards_short":      "RK",
+		"suspensions":          "Sperren",
+		"reason":               "Grund",
+		"suspended_for":        "Gesperrt für",
+		"next_match":           "Nächstes Spiel",
+		"red_card":             "Rote Karte",
+		"yellow_cards":         "Gelbsperre",
+		"bracket_path":         "Weg durch die Finalrunde",
+		"won":                  "Sieg",
+		"lost":                 "Niederlage",
+		"if_win":               "Bei Sieg: %s",
+		"if_lose":              "Bei Niederlage: %s",
+		"final_position":       "%s Platz",
+		"choose_team":          "Mannschaft wählen",
+		"bracket":              "Turnierbaum",
+		"bracket_places":       "Plätze %d bis %d",
+		"upcoming_matches":     "Nächste Spiele",
+		"latest_results":       "Letzte Ergebnisse",
+		"no_matches":           "Kein Spiel",
+		"match_sheet":          "Spielbericht",
+		"referee":              "Schiedsrichter",
+		"signatures":           "Unterschriften",
+		"captain":              "Kapitän",
+		"cards":                "Karten",
+		"certificate":          "Urkunde",
+		"opponent":             "Gegner: %s",
+		"result":               "Ergebnis: %s",
+		"calendar":             "Kalender",
+		"live":                 "LIVE",
+		"half_time":            "Halbzeit",
+		"pool_scenarios":       "Szenarien Gruppe %s",
+		"scenarios":            "Szenarien",
+		"if_pool_ended_now":    "Wenn die Gruppe jetzt enden würde",
+		"provisional":          "derzeit %s",
+		"remaining_matches":    "Verbleibende Spiele",
+		"possible_ranks":       "Mögliche Plätze",
+		"qualifying_places":    "Die ersten %d erreichen die Finalrunde",
+		"qualified":            "Qualifiziert",
+		"eliminated":           "Ausgeschieden",
+		"still_open":           "Hängt von anderen Ergebnissen ab",
+		"win_against":          "Sieg gegen %s",
+		"draw_against":         "Unentschieden gegen %s",
+		"defeat_against":       "Niederlage gegen %s",
+		"with_goal_margins":    "Mit Tordifferenzen",
+		"results_only":         "Nur Ergebnisse",
+		"too_many_scenarios":   "Zu viele Spiele stehen noch aus, um die Szenarien zu berechnen.",
+		"results_only_note":    "Ohne Tordifferenzen können punktgleiche Mannschaften in beliebiger Reihenfolge enden.",
+		"margins_limit_note":   "Tordifferenzen bis zu %d Toren berücksichtigt: ein höherer Sieg kann diese Szenarien noch ändern.",
+		"best_rank_team":       "Bester Gruppen-%[2]s Nr. %[1]d",
+		"best_ranked":          "Tabelle der Gruppen-%s",
+		"best_ranked_note":     "Ohne die Ergebnisse gegen Mannschaften hinter Platz %s.",
+		"best_ranked_average":  "Durchschnitt pro Spiel in Klammern, maßgeblich für die Rangfolge.",
+		"shared_place":         "punktgleich",
+	},
+	"es": {
+		"tournaments":          "Torneos",
+		"tournament":           "Torneo",
+		"name":                 "Nombre",
+		"pools":                "Grupos",
+		"ranking":              "Clasificación",
+		"rankings":             "Clasificaciones",
+		"matches":              "Partidos",
+		"all_matches":          "Todos los partidos",
+		"all_pools_matches":    "Partidos de todos los grupos",
+		"pool_matches":         "Partidos grupo %s",
+		"all_pools_ranking":    "Clasificación de todos los grupos",
+		"pool_ranking":         "Clasificación grupo %s",
+		"ranking_matches":      "Partidos de clasificación",
+		"final_ranking":        "Clasificación final",
+		"tournament_matches":   "Partidos %s",
+		"tournament_ranking":   "Clasificación %s",
+		"tournament_final":     "%s clasificación final",
+		"pool":                 "Grupo %s",
+		"pitch":                "Campo %s",
+		"time":                 "Hora",
+		"team":                 "Equipo",
+		"score":                "Resultado",
+		"match":                "Partido",
+		"points_short":         "Pts",
+		"played_short":         "PJ",
+		"wins_short":           "G",
+		"draws_short":          "E",
+		"defeats_short":        "P",
+		"goals_for_short":      "GF",
+		"goals_against_short":  "GC",
+		"goal_balance_short":   "DG",
+		"attack_rank":          "# Ataque",
+		"defense_rank":         "# Defensa",
+		"pool_rank_team":       "%[1]s del grupo %[2]s",
+		"winner_of_match":      "Ganador partido %s",
+		"loser_of_match":       "Perdedor partido %s",
+		"after_extra_time":     "pró.",
+		"penalty_shoot_out":    "pen.",
+		"home_forfeit":         "incomparecencia",
+		"visitor_forfeit":      "incomparecencia",
+		"double_forfeit":       "doble incomparecencia",
+		"abandoned":            "suspendido",
+		"awarded":              "en los despachos",
+		"sets_won_short":       "Sets G",
+		"sets_lost_short":      "Sets P",
+		"set_ratio":            "Coef. sets",
+		"point_ratio":          "Coef. puntos",
+		"points_for_short":     "PF",
+		"points_against_short": "PC",
+		"point_balance_short":  "Dif",
+		"top_scorers":          "Máximos goleadores",
+		"all_top_scorers":      "Máximos goleadores de todos los torneos",
+		"player":               "Jugador",
+		"number_short":         "Nº",
+		"goals":                "Goles",
+		"fair_play":            "Juego limpio",
+		"yellow_cards_short":   "TA",
+		"red_cards_short":      "TR",
+		"suspensions":          "Sanciones",
+		"reason":               "Motivo",
+		"suspended_for":        "Sancionado para",
+		"next_match":           "Próximo partido",
+		"red_card":             "Tarjeta roja",
+		"yellow_cards":         "Acumulación de tarjetas amarillas",
+		"bracket_path":         "Camino en la fase final",
+		"won":                  "Victoria",
+		"lost":                 "Derrota",
+		"if_win":               "Si gana: %s",
+		"if_lose":              "Si pierde: %s",
+		"final_position":       "%s puesto",
+		"choose_team":          "Elegir un equipo",
+		"bracket":              "Cuadro",
+		"bracket_places":       "Puestos %d a %d",
+		"upcoming_matches":     "Próximos partidos",
+		"latest_results":       "Últimos resultados",
+		"no_matches":           "Ningún partido",
+		"match_sheet":          "Acta del partido",
+		"referee":              "Árbitro",
+		"signatures":           "Firmas",
+		"captain":              "Capitán",
+		"cards":                "Tarjetas",
+		"certificate":          "Diploma",
+		"opponent":             "Rival: %s",
+		"result":               "Resultado: %s",
+		"calendar":             "Calendario",
+		"live":                 "EN VIVO",
+		"half_time":            "Descanso",
+		"pool_scenarios":       "Escenarios grupo %s",
+		"scenarios":            "Escenarios",
+		"if_pool_ended_now":    "Si el grupo terminara ahora",
+		"provisional":          "provisionalmente %s",
+		"remaining_matches":    "Partidos restantes",
+		"possible_ranks":       "Puestos posibles",
+		"qualifying_places":    "Los %d primeros pasan al cuadro final",
+		"qualified":            "Clasificado",
+		"eliminated":           "Eliminado",
+		"still_open":           "Depende de otros resultados",
+		"win_against":          "victoria contra %s",
+		"draw_against":         "empate contra %s",
+		"defeat_against":       "derrota contra %s",
+		"with_goal_margins":    "Con diferencias de goles",
+		"results_only":         "Solo resultados",
+		"too_many_scenarios":   "Quedan demasiados partidos para calcular los escenarios.",
+		"results_only_note":    "Sin diferencias de goles, los equipos empatados a puntos pueden terminar en cualquier orden.",
+		"margins_limit_note":   "Diferencias de goles consideradas hasta %d goles: una diferencia mayor aún puede cambiar estos escenarios.",
+		"best_rank_team":       "Mejor %[2]s n.º %[1]d",
+		"best_ranked":          "Clasificación de los %s de grupo",
+		"best_ranked_note":     "Sin los resultados contra los equipos clasificados por detrás del %s puesto.",
+		"best_ranked_average":  "Promedios por partido entre paréntesis, usados para la clasificación.",
+		"shared_place":         "empatado",
+	},
+}
+
+// translate returns the message of the locale, falling back to the default locale and then to the message ID.
+func translate(locale string, id string, args ...interface{}) string {
+	message, ok := messages[locale][id]
+	if !ok {
+		message, ok = messages[defaultLocale][id]
+	}
+	if !ok {
+		message = id
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// ordinal renders a rank the way it is written in the locale: 1er, 1st, 1., 1º...
+func ordinal(locale string, n int) string {
+	switch locale {
+	case "en":
+		suffix := "th"
+		if n%100 < 11 || n%100 > 13 {
+			switch n % 10 {
+			case 1:
+				suffix = "st"
+			case 2:
+				suffix = "nd"
+			case 3:
+				suffix = "rd"
+			}
+		}
+		return fmt.Sprintf("%d%s", n, suffix)
+	case "de":
+		return fmt.Sprintf("%d.", n)
+	case "es":
+		return fmt.Sprintf("%dº", n)
+	default:
+		if n == 1 {
+			return "1er"
+		}
+		return fmt.Sprintf("%deme", n)
+	}
+}
+
+func supportedLocale(code string) bool {
+	return funk.Find(locales, func(l locale) bool { return l.Code == code }) != nil
+}
+
+// requestLocale picks the locale of a public page: the lang query parameter (remembered in a cookie),
+// then the browser Accept-Language header, then the tournament locale.
+func requestLocale(c echo.Context, tournamentLocale string) string {
+	if lang := c.QueryParam("lang"); supportedLocale(lang) {
+		c.SetCookie(&http.Cookie{Name: "lang", Value: lang, Path: "/", MaxAge: 365 * 24 * 3600})
+		return lang
+	}
+	if cookie, err := c.Cookie("lang"); err == nil && supportedLocale(cookie.Value) {
+		return cookie.Value
+	}
+	if lang := acceptedLocale(c.Request().Header.Get("Accept-Language")); lang != "" {
+		return lang
+	}
+	if supportedLocale(tournamentLocale) {
+		return tournamentLocale
+	}
+	return defaultLocale
+}
+
+// localeLinks links every locale to the current page, keeping its other query parameters such as the
+// from and to times of the match lists.
+func localeLinks(c echo.Context) []localeLink {
+	links := make([]localeLink, 0)
+	for _, l := range locales {
+		query := url.Values{}
+		for key, values := range c.QueryParams() {
+			query[key] = values
+		}
+		query.Set("lang", l.Code)
+		links = append(links, localeLink{locale: l, URL: "?" + query.Encode()})
+	}
+	return links
+}
+
+// acceptedLocale returns the supported locale with the highest quality in an Accept-Language header.
+func acceptedLocale(header string) string {
+	type language struct {
+		code    string
+		quality float64
+	}
+	languages := make([]language, 0)
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		code := strings.ToLower(strings.SplitN(fields[0], "-", 2)[0])
+		quality := 1.0
+		for _, param := range fields[1:] {
+			if strings.HasPrefix(strings.TrimSpace(param), "q=") {
+				quality, _ = strconv.ParseFloat(strings.TrimPrefix(strings.TrimSpace(param), "q="), 64)
+			}
+		}
+		if supportedLocale(code) && quality > 0 {
+			languages = append(languages, language{code, quality})
+		}
+	}
+	sort.SliceStable(languages, func(i, j int) bool { return languages[i].quality > languages[j].quality })
+	if len(languages) == 0 {
+		return ""
+	}
+	return languages[0].code
+}
+
+// templateFuncs are the functions available in every view.
+var templateFuncs = template.FuncMap{
+	"t":       translate,
+	"ordinal": ordinal,
+	"dict":    dict,
+	"ratio":   formatRatio,
+	"sets":    formatSets,
+}
+
+// dict builds a map from key/value pairs, to pass several values to a template.
+func dict(values ...interface{}) map[string]interface{} {
+	m := make(map[string]interface{})
+	for i := 0; i+1 < len(values); i += 2 {
+		m[values[i].(string)] = values[i+1]
+	}
+	return m
+}
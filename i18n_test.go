@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+)
+
+func TestOrdinal(t *testing.T) {
+	cases := []struct {
+		locale   string
+		n        int
+		expected string
+	}{
+		{"fr", 1, "1er"},
+		{"fr", 2, "2eme"},
+		{"en", 1, "1st"},
+		{"en", 2, "2nd"},
+		{"en", 3, "3rd"},
+		{"en", 11, "11th"},
+		{"en", 22, "22nd"},
+		{"de", 3, "3."},
+		{"es", 2, "2º"},
+	}
+	for _, c := range cases {
+		if actual := ordinal(c.locale, c.n); actual != c.expected {
+			t.Errorf("Expected %s for %d in %s, got %s.", c.expected, c.n, c.locale, actual)
+		}
+	}
+}
+
+func TestAcceptedLocale(t *testing.T) {
+	cases := map[string]string{
+		"de-DE,de;q=0.9,en;q=0.8": "de",
+		"it-IT,es;q=0.5,en;q=0.7": "en",
+		"ja,zh;q=0.5":             "",
+		"":                        "",
+		"en;q=0,fr-CH":            "fr",
+	}
+	for header, expected := range cases {
+		if actual := acceptedLocale(header); actual != expected {
+			t.Errorf("Expected %q for %q, got %q.", expected, header, actual)
+		}
+	}
+}
+
+func TestTranslateFallsBackToDefaultLocale(t *testing.T) {
+	if actual := translate("it", "pool", "A"); actual != "Poule A" {
+		t.Errorf("Expected Poule A, got %s.", actual)
+	}
+	if actual := translate("en", "pool_rank_team", ordinal("en", 2), "B"); actual != "2nd in pool B" {
+		t.Errorf("Expected 2nd in pool B, got %s.", actual)
+	}
+}
+
+func TestLocaleLinksKeepQueryParameters(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/tournaments/T1/pools-matches?from=09:00&to=12:00&lang=fr", nil)
+	c := echo.New().NewContext(req, httptest.NewRecorder())
+	links := localeLinks(c)
+	if len(links) != len(locales) {
+		t.Fatalf("Expected a link per locale, got %v.", links)
+	}
+	if expected := "?from=09%3A00&lang=en&to=12%3A00"; links[1].Code != "en" || links[1].URL != expected {
+		t.Errorf("Expected %q, got %q.", expected, links[1].URL)
+	}
+	if c.QueryParam("lang") != "fr" {
+		t.Errorf("Expected the request query to be left untouched, got %q.", c.QueryParam("lang"))
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	liveScheduled  = "scheduled"
+	liveInProgress = "in_progress"
+	liveHalfTime   = "half_time"
+	liveFinished   = "finished"
+)
+
+const (
+	liveActionStart             = "start"
+	liveActionHalfTime          = "half_time"
+	liveActionResume            = "resume"
+	liveActionHomeGoal          = "home_goal"
+	liveActionVisitorGoal       = "visitor_goal"
+	liveActionCancelHomeGoal    = "cancel_home_goal"
+	liveActionCancelVisitorGoal = "cancel_visitor_goal"
+	liveActionFinish            = "finish"
+)
+
+var errInvalidLiveAction = errors.New("invalid live action")
+
+// liveSubmission is an action of the scorekeeper on a match played live, identified like scoreSubmission.
+type liveSubmission struct {
+	SubmissionID string `json:"submissionId"`
+	Ref          string `json:"ref"`
+	Action       string `json:"action"`
+	// At is when the action was tapped, in milliseconds since the epoch.
+	At int64 `json:"at"`
+}
+
+func (s liveSubmission) Valid() bool {
+	return s.SubmissionID != "" && len(s.SubmissionID) <= 64 && s.Ref != ""
+}
+
+// Time is when the action was tapped, now when the phone did not tell or its clock is ahead.
+func (s liveSubmission) Time(now time.Time) time.Time {
+	at := time.Unix(0, s.At*int64(time.Millisecond))
+	if s.At <= 0 || at.After(now) {
+		return now
+	}
+	return at
+}
+
+// liveMatch is the running state of a match followed live. The clock is running while ClockStartedAt is
+// set, Elapsed being the time played before. Matches never started have no live state and are scheduled.
+type liveMatch struct {
+	Status         string
+	Elapsed        time.Duration
+	ClockStartedAt NullTime
+	// Seconds and Minute are the time played when the match was loaded, Minute being 34 between 33:00 and 34:00.
+	Seconds int
+	Minute  int
+}
+
+// InProgress tells whether the match has started and is not over, its score not being final yet.
+func (l liveMatch) InProgress() bool {
+	return l.Status == liveInProgress || l.Status == liveHalfTime
+}
+
+func (l liveMatch) ElapsedAt(now time.Time) time.Duration {
+	if !l.ClockStartedAt.Valid || now.Before(l.ClockStartedAt.Time) {
+		return l.Elapsed
+	}
+	return l.Elapsed + now.Sub(l.ClockStartedAt.Time)
+}
+
+func (l liveMatch) MinuteAt(now time.Time) int {
+	return int(l.ElapsedAt(now)/time.Minute) + 1
+}
+
+// Label is shown before the running score, e.g. "LIVE 34'".
+func (l liveMatch) Label(locale string) string {
+	if l.Status == liveHalfTime {
+		return translate(locale, "live") + " " + translate(locale, "half_time")
+	}
+	return fmt.Sprintf("%s %d'", translate(locale, "live"), l.Minute)
+}
+
+// liveMatchAfter applies an action of the scorekeeper at the given time, the goals being handled apart.
+func liveMatchAfter(live liveMatch, action string, at time.Time) (liveMatch, error) {
+	stopClock := func() {
+		live.Elapsed = live.ElapsedAt(at)
+		live.ClockStartedAt = NullTime{}
+	}
+	switch {
+	case action == liveActionStart && (live.Status == "" || live.Status == liveScheduled):
+		live = liveMatch{Status: liveInProgress, ClockStartedAt: NullTime{Time: at, Valid: true}}
+	case action == liveActionHalfTime && live.Status == liveInProgress:
+		stopClock()
+		live.Status = liveHalfTime
+	case action == liveActionResume && live.Status == liveHalfTime:
+		live.Status = liveInProgress
+		live.ClockStartedAt = NullTime{Time: at, Valid: true}
+	case action == liveActionFinish && live.InProgress():
+		stopClock()
+		live.Status = liveFinished
+	case (action == liveActionHomeGoal || action == liveActionVisitorGoal || action == liveActionCancelHomeGoal || action == liveActionCancelVisitorGoal) && live.InProgress():
+	default:
+		return live, errInvalidLiveAction
+	}
+	return live, nil
+}
+
+// loadLiveMatches returns the live state of the matches of the tournament by match reference, with the
+// minute being played now.
+func loadLiveMatches(db *sql.DB, tournamentID string) map[string]liveMatch {
+	now := time.Now()
+	live := selectLiveMatches(db, tournamentID)
+	for ref, l := range live {
+		l.Seconds = int(l.ElapsedAt(now) / time.Second)
+		l.Minute = l.MinuteAt(now)
+		live[ref] = l
+	}
+	return live
+}
+
+// applyLiveAction moves the live match on, at being when the scorekeeper tapped the action so that the
+// clock stays right for actions replayed from an offline queue. Finishing the match records its score
+// as the result, which ranking matches can only do without a draw, and drops the sets entered before.
+func applyLiveAction(db *sql.DB, tournament tournament, ref string, action string, at time.Time) error {
+	match, err := findScoreableMatch(db, tournament, ref)
+	if err != nil {
+		return err
+	}
+	poolIndex, matchID, key, err := parseMatchRef(ref)
+	if err != nil {
+		return errUnknownMatch
+	}
+	live, err := liveMatchAfter(selectLiveMatches(db, tournament.ID)[ref], action, at)
+	if err != nil || (action == liveActionStart && match.Played) {
+		return errInvalidLiveAction
+	}
+	switch action {
+	case liveActionStart:
+		addLiveGoals(db, tournament.ID, ref, 0, 0)
+	case liveActionHomeGoal:
+		addLiveGoals(db, tournament.ID, ref, 1, 0)
+	case liveActionVisitorGoal:
+		addLiveGoals(db, tournament.ID, ref, 0, 1)
+	case liveActionCancelHomeGoal:
+		addLiveGoals(db, tournament.ID, ref, -1, 0)
+	case liveActionCancelVisitorGoal:
+		addLiveGoals(db, tournament.ID, ref, 0, -1)
+	case liveActionFinish:
+		homeTeamGoals, visitorTeamGoals := int(match.HomeTeamGoals.Int64), int(match.VisitorTeamGoals.Int64)
+		if key != "" && homeTeamGoals == visitorTeamGoals {
+			return errInvalidScore
+		}
+		before := loadWebhookState(db, tournament.ID)
+		saveLiveMatch(db, tournament.ID, ref, live)
+		saveMatchSets(db, tournament.ID, ref, nil)
+		if key == "" {
+			savePoolMatchScore(db, tournament.ID, poolIndex, matchID, resultPlayed, homeTeamGoals, visitorTeamGoals)
+			completePool(db, tournament.ID, poolIndex)
+		} else {
+			recordRankingMatchResult(db, tournament.ID, key, resultPlayed, rankingMatchScore{HomeTeamGoals: homeTeamGoals, VisitorTeamGoals: visitorTeamGoals})
+			applyWithdrawals(db, tournament.ID)
+		}
+		fireWebhooks(db, tournament.ID, ref, before)
+		return nil
+	}
+	saveLiveMatch(db, tournament.ID, ref, live)
+	return nil
+}
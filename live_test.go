@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLiveMatchClock(t *testing.T) {
+	kickOff := time.Date(2024, 6, 15, 14, 0, 0, 0, time.UTC)
+	live, err := liveMatchAfter(liveMatch{}, liveActionStart, kickOff)
+	if err != nil || live.Status != liveInProgress {
+		t.Fatalf("Expected the match to start, got %v %v.", live, err)
+	}
+	if minute := live.MinuteAt(kickOff.Add(33*time.Minute + 10*time.Second)); minute != 34 {
+		t.Errorf("Expected the 34th minute, got %d.", minute)
+	}
+	live, _ = liveMatchAfter(live, liveActionHalfTime, kickOff.Add(20*time.Minute))
+	if live.Status != liveHalfTime || live.ElapsedAt(kickOff.Add(30*time.Minute)) != 20*time.Minute {
+		t.Errorf("Expected the clock to stop at half-time, got %v.", live)
+	}
+	live, _ = liveMatchAfter(live, liveActionResume, kickOff.Add(25*time.Minute))
+	if elapsed := live.ElapsedAt(kickOff.Add(30 * time.Minute)); elapsed != 25*time.Minute {
+		t.Errorf("Expected the clock to resume from half-time, got %v.", elapsed)
+	}
+	live, _ = liveMatchAfter(live, liveActionFinish, kickOff.Add(45*time.Minute))
+	if live.Status != liveFinished || live.InProgress() || live.Elapsed != 40*time.Minute {
+		t.Errorf("Expected the match to be finished after 40 minutes, got %v.", live)
+	}
+	if _, err := liveMatchAfter(live, liveActionHomeGoal, kickOff.Add(50*time.Minute)); err != errInvalidLiveAction {
+		t.Errorf("Expected no goal after the end of the match.")
+	}
+	if _, err := liveMatchAfter(liveMatch{}, liveActionResume, kickOff); err != errInvalidLiveAction {
+		t.Errorf("Expected a match not started not to resume.")
+	}
+}
+
+func TestLiveMatchScore(t *testing.T) {
+	match := poolMatch{HomeTeamGoals: nullInt(1), VisitorTeamGoals: nullInt(0), ResultStatus: resultPlayed, Live: liveMatch{Status: liveInProgress, Minute: 34}}
+	if score := match.Score("fr"); score != "LIVE 34' 1–0" {
+		t.Errorf("Expected the running score, got %q.", score)
+	}
+	if match.Played() {
+		t.Errorf("Expected a match in progress not to be played.")
+	}
+	match.Live.Status = liveHalfTime
+	if score := match.Score("en"); score != "LIVE Half-time 1–0" {
+		t.Errorf("Expected the half-time score, got %q.", score)
+	}
+	match.Live.Status = liveFinished
+	if score := match.Score("fr"); score != "1–0" || !match.Played() {
+		t.Errorf("Expected the final score, got %q.", score)
+	}
+}
+
+func TestLiveSubmissionTime(t *testing.T) {
+	now := time.Date(2024, 6, 15, 14, 0, 0, 0, time.UTC)
+	tapped := now.Add(-5 * time.Minute)
+	if at := (liveSubmission{At: tapped.UnixNano() / int64(time.Millisecond)}).Time(now); !at.Equal(tapped) {
+		t.Errorf("Expected the time of the tap, got %v.", at)
+	}
+	if at := (liveSubmission{At: now.Add(time.Hour).UnixNano() / int64(time.Millisecond)}).Time(now); !at.Equal(now) {
+		t.Errorf("Expected now for a phone clock ahead, got %v.", at)
+	}
+}
@@ -2,17 +2,108 @@ package main
 
 import (
 	"database/sql"
+	"fmt"
 	"time"
 )
 
+const (
+	statusDraft      = "draft"
+	statusPublished  = "published"
+	statusInProgress = "in_progress"
+	statusFinished   = "finished"
+	statusArchived   = "archived"
+)
+
+type tournamentStatus struct {
+	Value string
+	Label string
+}
+
+var tournamentStatuses = []tournamentStatus{
+	{statusDraft, "Brouillon"},
+	{statusPublished, "Publié"},
+	{statusInProgress, "En cours"},
+	{statusFinished, "Terminé"},
+	{statusArchived, "Archivé"},
+}
+
+const (
+	resultPlayed         = "played"
+	resultHomeForfeit    = "home_forfeit"
+	resultVisitorForfeit = "visitor_forfeit"
+	resultDoubleForfeit  = "double_forfeit"
+	resultAbandoned      = "abandoned"
+	resultAwarded        = "awarded"
+)
+
+type resultStatus struct {
+	Value string
+	Label string
+}
+
+var resultStatuses = []resultStatus{
+	{resultPlayed, "Joué"},
+	{resultHomeForfeit, "Forfait domicile"},
+	{resultVisitorForfeit, "Forfait visiteur"},
+	{resultDoubleForfeit, "Double forfait"},
+	{resultAbandoned, "Arrêté"},
+	{resultAwarded, "Sur tapis vert"},
+}
+
+// rankingMatchResultStatuses are the result statuses which designate a winner, as needed by the bracket.
+var rankingMatchResultStatuses = resultStatuses[:3]
+
 type tournament struct {
-	ID              string
-	Name            string
-	pointsPerWin    float64
-	pointsPerDraw   float64
-	pointsPerDefeat float64
-	pointsPerGoal   float64
-	Pools           []pool
+	ID                  string
+	Name                string
+	Status              string
+	DeletedAt           sql.NullString
+	pointsPerWin        float64
+	pointsPerDraw       float64
+	pointsPerDefeat     float64
+	pointsPerGoal       float64
+	GameDurationMinutes int
+	MinRestMinutes      int
+	PlayingWindows      string
+	Locale              string
+	ForfeitGoals        int
+	// ForfeitPenaltyPoints are taken off the points of a team for each forfeit.
+	ForfeitPenaltyPoints float64
+	// ForfeitGoalsCounted tells whether the goals of forfeited and awarded matches count in the goal difference.
+	ForfeitGoalsCounted bool
+	ScoringModel        string
+	// YellowCardPoints and RedCardPoints weigh the cards in the fair-play ranking.
+	YellowCardPoints float64
+	RedCardPoints    float64
+	// YellowCardsPerSuspension is the number of yellow cards after which a player misses the next match,
+	// zero meaning yellow cards never lead to a suspension.
+	YellowCardsPerSuspension int
+	FairPlayTieBreak         bool
+	// Date is the first day of the tournament as 2006-01-02, changing it moving every match by as many days.
+	// Matches are scheduled at a date and a time of day in TimeZone, an IANA time zone name.
+	Date     string
+	TimeZone string
+	// Normalisation is the way teams of pools of different sizes are compared.
+	Normalisation string
+	// EliminatedRanking is the way the teams eliminated in pools are placed in the final ranking.
+	EliminatedRanking string
+	Pools             []pool
+	Teams             []team
+}
+
+// MatchTime is the instant at which a match scheduled at a date and time of day of the tournament time zone
+// takes place.
+func (t tournament) MatchTime(scheduledAt time.Time) (time.Time, error) {
+	location, err := time.LoadLocation(t.TimeZone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(scheduledAt.Year(), scheduledAt.Month(), scheduledAt.Day(), scheduledAt.Hour(), scheduledAt.Minute(), 0, 0, location), nil
+}
+
+// Listed tells whether the tournament appears on the public index.
+func (t tournament) Listed() bool {
+	return t.Status == statusPublished || t.Status == statusInProgress
 }
 
 type poolMatch struct {
@@ -25,7 +116,34 @@ type poolMatch struct {
 	VisitorTeamID    int
 	HomeTeamGoals    sql.NullInt64
 	VisitorTeamGoals sql.NullInt64
+	ResultStatus     string
+	PitchID          int
 	PitchName        string
+	Sets             []setScore
+	Live             liveMatch
+}
+
+// Played tells whether the match has its final score, matches in progress having a running score only.
+func (m poolMatch) Played() bool {
+	return m.HomeTeamGoals.Valid && !m.Live.InProgress()
+}
+
+// Score renders the result of the match, e.g. "3–0 (forfait)", or its running score, e.g. "LIVE 34' 1–0".
+func (m poolMatch) Score(locale string) string {
+	if !m.HomeTeamGoals.Valid || !m.VisitorTeamGoals.Valid {
+		return ""
+	}
+	score := fmt.Sprintf("%d–%d", m.HomeTeamGoals.Int64, m.VisitorTeamGoals.Int64)
+	if m.Live.InProgress() {
+		return m.Live.Label(locale) + " " + score
+	}
+	if len(m.Sets) > 0 {
+		score = fmt.Sprintf("%s (%s)", score, formatSets(m.Sets))
+	}
+	if m.ResultStatus != "" && m.ResultStatus != resultPlayed {
+		score = fmt.Sprintf("%s (%s)", score, translate(locale, m.ResultStatus))
+	}
+	return score
 }
 
 type rankingMatch struct {
@@ -45,11 +163,104 @@ type rankingMatch struct {
 	VisitorTeamName                     sql.NullString
 	VisitorTeamGoals                    sql.NullInt64
 	VisitorTeamID                       sql.NullInt64
+	HomeTeamExtraTimeGoals              sql.NullInt64
+	VisitorTeamExtraTimeGoals           sql.NullInt64
+	HomeTeamPenaltyGoals                sql.NullInt64
+	VisitorTeamPenaltyGoals             sql.NullInt64
+	ResultStatus                        string
+	Sets                                []setScore
 	WinnerTeamID                        sql.NullInt64
 	LooserTeamID                        sql.NullInt64
+	WinnerFinalRank                     sql.NullInt64
+	LooserFinalRank                     sql.NullInt64
 	ValidTeams                          bool
+	PitchID                             int
 	PitchName                           string
-	PenaltyShootOutWinner               string
+	Live                                liveMatch
+	// HomeTeamBestRank and HomeTeamBestPosition designate the team at HomeTeamBestPosition among the teams
+	// at rank HomeTeamBestRank of every pool, e.g. the second best third-placed team; same for the visitor.
+	HomeTeamBestRank        sql.NullInt64
+	HomeTeamBestPosition    sql.NullInt64
+	VisitorTeamBestRank     sql.NullInt64
+	VisitorTeamBestPosition sql.NullInt64
+	// HomeTeamProvisional and VisitorTeamProvisional hold the pool places while the pools are played.
+	HomeTeamProvisional    provisionalTeam
+	VisitorTeamProvisional provisionalTeam
+}
+
+// Played tells whether the match has its final score, matches in progress having a running score only.
+func (m rankingMatch) Played() bool {
+	return m.HomeTeamGoals.Valid && !m.Live.InProgress()
+}
+
+// Score renders the result of the match, e.g. "2–2 (a.p.) 4–3 t.a.b.", or its running score.
+func (m rankingMatch) Score(locale string) string {
+	if !m.HomeTeamGoals.Valid || !m.VisitorTeamGoals.Valid {
+		return ""
+	}
+	score := fmt.Sprintf("%d–%d", m.HomeTeamGoals.Int64, m.VisitorTeamGoals.Int64)
+	if m.Live.InProgress() {
+		return m.Live.Label(locale) + " " + score
+	}
+	if m.HomeTeamExtraTimeGoals.Valid && m.VisitorTeamExtraTimeGoals.Valid {
+		score = fmt.Sprintf("%d–%d (%s)", m.HomeTeamExtraTimeGoals.Int64, m.VisitorTeamExtraTimeGoals.Int64, translate(locale, "after_extra_time"))
+	}
+	if m.HomeTeamPenaltyGoals.Valid && m.VisitorTeamPenaltyGoals.Valid {
+		score = fmt.Sprintf("%s %d–%d %s", score, m.HomeTeamPenaltyGoals.Int64, m.VisitorTeamPenaltyGoals.Int64, translate(locale, "penalty_shoot_out"))
+	}
+	if len(m.Sets) > 0 {
+		score = fmt.Sprintf("%s (%s)", score, formatSets(m.Sets))
+	}
+	if m.ResultStatus != "" && m.ResultStatus != resultPlayed {
+		score = fmt.Sprintf("%s (%s)", score, translate(locale, m.ResultStatus))
+	}
+	return score
+}
+
+// rankingMatchScore is the result of a ranking match. Extra time goals include the regulation goals,
+// penalty goals are only those of the shoot-out.
+type rankingMatchScore struct {
+	HomeTeamGoals             int
+	VisitorTeamGoals          int
+	HomeTeamExtraTimeGoals    sql.NullInt64
+	VisitorTeamExtraTimeGoals sql.NullInt64
+	HomeTeamPenaltyGoals      sql.NullInt64
+	VisitorTeamPenaltyGoals   sql.NullInt64
+}
+
+// Valid tells whether the score designates a winner: extra time is only played after a draw, goals
+// cannot be taken back during extra time, and the shoot-out breaks a draw after regulation or extra time.
+func (s rankingMatchScore) Valid() bool {
+	if s.HomeTeamGoals < 0 || s.VisitorTeamGoals < 0 {
+		return false
+	}
+	if s.HomeTeamExtraTimeGoals.Valid != s.VisitorTeamExtraTimeGoals.Valid || s.HomeTeamPenaltyGoals.Valid != s.VisitorTeamPenaltyGoals.Valid {
+		return false
+	}
+	home, visitor := int64(s.HomeTeamGoals), int64(s.VisitorTeamGoals)
+	if s.HomeTeamExtraTimeGoals.Valid {
+		if home != visitor || s.HomeTeamExtraTimeGoals.Int64 < home || s.VisitorTeamExtraTimeGoals.Int64 < visitor {
+			return false
+		}
+		home, visitor = s.HomeTeamExtraTimeGoals.Int64, s.VisitorTeamExtraTimeGoals.Int64
+	}
+	if home != visitor {
+		return !s.HomeTeamPenaltyGoals.Valid
+	}
+	return s.HomeTeamPenaltyGoals.Valid && s.HomeTeamPenaltyGoals.Int64 >= 0 && s.VisitorTeamPenaltyGoals.Int64 >= 0 &&
+		s.HomeTeamPenaltyGoals.Int64 != s.VisitorTeamPenaltyGoals.Int64
+}
+
+// HomeTeamWins tells whether the home team wins a valid score.
+func (s rankingMatchScore) HomeTeamWins() bool {
+	switch {
+	case s.HomeTeamPenaltyGoals.Valid:
+		return s.HomeTeamPenaltyGoals.Int64 > s.VisitorTeamPenaltyGoals.Int64
+	case s.HomeTeamExtraTimeGoals.Valid:
+		return s.HomeTeamExtraTimeGoals.Int64 > s.VisitorTeamExtraTimeGoals.Int64
+	default:
+		return s.HomeTeamGoals > s.VisitorTeamGoals
+	}
 }
 
 type pool struct {
@@ -71,10 +282,33 @@ type rankingViewModel struct {
 	TeamRankings []teamRanking
 }
 
+type pitch struct {
+	ID   int
+	Name string
+}
+
+type checklistItem struct {
+	Label    string
+	Problems []string
+}
+
+func (item checklistItem) OK() bool {
+	return len(item.Problems) == 0
+}
+
 type team struct {
 	ID        int
 	Name      string
 	PoolIndex int
+	Withdrawn bool
+}
+
+type player struct {
+	ID        int
+	TeamID    int
+	Name      string
+	Number    sql.NullInt64
+	LicenceID string
 }
 
 type teamRanking struct {
@@ -91,11 +325,18 @@ type teamRanking struct {
 	Rank          int
 	AttackRank    int
 	DefenseRank   int
+	SetRatio      float64
+	PointRatio    float64
+	// FairPlayPoints sums the cards received in the pool.
+	FairPlayPoints float64
 }
 
 type tournamentFinalRanking struct {
 	Rank          int
+	Shared        bool
+	TeamID        sql.NullInt64
 	TeamName      sql.NullString
+	Played        sql.NullInt64
 	TeamGoals     sql.NullInt64
 	OpponentGoals sql.NullInt64
 	GoalBalance   sql.NullInt64
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestRankingMatchScoreValidity(t *testing.T) {
+	cases := []struct {
+		score    rankingMatchScore
+		expected bool
+	}{
+		{rankingMatchScore{HomeTeamGoals: 2, VisitorTeamGoals: 1}, true},
+		{rankingMatchScore{HomeTeamGoals: 1, VisitorTeamGoals: 1}, false},
+		{rankingMatchScore{HomeTeamGoals: 1, VisitorTeamGoals: 1, HomeTeamPenaltyGoals: nullInt(4), VisitorTeamPenaltyGoals: nullInt(3)}, true},
+		{rankingMatchScore{HomeTeamGoals: 1, VisitorTeamGoals: 1, HomeTeamPenaltyGoals: nullInt(3), VisitorTeamPenaltyGoals: nullInt(3)}, false},
+		{rankingMatchScore{HomeTeamGoals: 2, VisitorTeamGoals: 1, HomeTeamPenaltyGoals: nullInt(4), VisitorTeamPenaltyGoals: nullInt(3)}, false},
+		{rankingMatchScore{HomeTeamGoals: 1, VisitorTeamGoals: 1, HomeTeamExtraTimeGoals: nullInt(1), VisitorTeamExtraTimeGoals: nullInt(2)}, true},
+		{rankingMatchScore{HomeTeamGoals: 2, VisitorTeamGoals: 1, HomeTeamExtraTimeGoals: nullInt(2), VisitorTeamExtraTimeGoals: nullInt(2)}, false},
+		{rankingMatchScore{HomeTeamGoals: 1, VisitorTeamGoals: 1, HomeTeamExtraTimeGoals: nullInt(0), VisitorTeamExtraTimeGoals: nullInt(2)}, false},
+		{rankingMatchScore{HomeTeamGoals: 1, VisitorTeamGoals: 1, HomeTeamExtraTimeGoals: nullInt(2), VisitorTeamExtraTimeGoals: nullInt(2)}, false},
+		{rankingMatchScore{HomeTeamGoals: 1, VisitorTeamGoals: 1, HomeTeamExtraTimeGoals: nullInt(2), VisitorTeamExtraTimeGoals: nullInt(2),
+			HomeTeamPenaltyGoals: nullInt(2), VisitorTeamPenaltyGoals: nullInt(4)}, true},
+		{rankingMatchScore{HomeTeamGoals: 1, VisitorTeamGoals: 1, HomeTeamExtraTimeGoals: nullInt(2)}, false},
+	}
+	for _, c := range cases {
+		if c.score.Valid() != c.expected {
+			t.Errorf("Expected validity %v for %+v.", c.expected, c.score)
+		}
+	}
+}
+
+func TestRankingMatchScoreWinner(t *testing.T) {
+	score := rankingMatchScore{HomeTeamGoals: 1, VisitorTeamGoals: 1, HomeTeamExtraTimeGoals: nullInt(2), VisitorTeamExtraTimeGoals: nullInt(2),
+		HomeTeamPenaltyGoals: nullInt(4), VisitorTeamPenaltyGoals: nullInt(3)}
+	if !score.HomeTeamWins() {
+		t.Errorf("Expected the home team to win the shoot-out.")
+	}
+	score = rankingMatchScore{HomeTeamGoals: 0, VisitorTeamGoals: 0, HomeTeamExtraTimeGoals: nullInt(0), VisitorTeamExtraTimeGoals: nullInt(1)}
+	if score.HomeTeamWins() {
+		t.Errorf("Expected the visitor team to win in extra time.")
+	}
+}
+
+func TestRankingMatchScoreLabel(t *testing.T) {
+	match := rankingMatch{
+		HomeTeamGoals: nullInt(1), VisitorTeamGoals: nullInt(1),
+		HomeTeamExtraTimeGoals: nullInt(2), VisitorTeamExtraTimeGoals: nullInt(2),
+		HomeTeamPenaltyGoals: nullInt(4), VisitorTeamPenaltyGoals: nullInt(3),
+	}
+	if actual := match.Score("fr"); actual != "2–2 (a.p.) 4–3 t.a.b." {
+		t.Errorf("Expected 2–2 (a.p.) 4–3 t.a.b., got %s.", actual)
+	}
+	match = rankingMatch{HomeTeamGoals: nullInt(3), VisitorTeamGoals: nullInt(0)}
+	if actual := match.Score("en"); actual != "3–0" {
+		t.Errorf("Expected 3–0, got %s.", actual)
+	}
+}
+
+func nullInt(value int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: value, Valid: true}
+}
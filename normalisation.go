@@ -0,0 +1,61 @@
+package main
+
+import "fmt"
+
+const (
+	normalisationNone    = "none"
+	normalisationAverage = "average"
+	normalisationWeakest = "exclude_weakest"
+)
+
+type normalisation struct {
+	Value string
+	Label string
+}
+
+// normalisations are the ways teams of pools of different sizes are compared, across pools and in the
+// attack and defense ranks of the final ranking.
+var normalisations = []normalisation{
+	{normalisationWeakest, "Sans les matchs contre les derniers des poules les plus grandes"},
+	{normalisationAverage, "Moyennes par match"},
+	{normalisationNone, "Aucune"},
+}
+
+func validNormalisation(value string) bool {
+	for _, n := range normalisations {
+		if n.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+// weakestTeamIDs returns the teams of the larger pools ranked after the size of the smallest pool, whose
+// matches are left out when comparing teams of pools of different sizes.
+func weakestTeamIDs(rankings map[int][]teamRanking) []int {
+	smallestPool := 0
+	for _, pool := range rankings {
+		if smallestPool == 0 || len(pool) < smallestPool {
+			smallestPool = len(pool)
+		}
+	}
+	ids := make([]int, 0)
+	for _, pool := range rankings {
+		for _, ranking := range pool[smallestPool:] {
+			ids = append(ids, ranking.ID)
+		}
+	}
+	return ids
+}
+
+// perGame divides a total by the number of matches played, zero before the first match.
+func perGame(total float64, played int) float64 {
+	if played == 0 {
+		return 0
+	}
+	return total / float64(played)
+}
+
+func formatPerGame(total float64, played int) string {
+	return fmt.Sprintf("%.2f", perGame(total, played))
+}
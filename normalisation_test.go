@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestWeakestTeamIDs(t *testing.T) {
+	rankings := map[int][]teamRanking{
+		1: {{ID: 1}, {ID: 2}, {ID: 3}},
+		2: {{ID: 4}, {ID: 5}, {ID: 6}, {ID: 7}},
+		3: {{ID: 8}, {ID: 9}, {ID: 10}, {ID: 11}, {ID: 12}},
+	}
+	ids := weakestTeamIDs(rankings)
+	if len(ids) != 3 {
+		t.Errorf("Expected the teams ranked after the 3rd place, got %v.", ids)
+	}
+	for _, id := range []int{7, 11, 12} {
+		found := false
+		for _, weakest := range ids {
+			found = found || weakest == id
+		}
+		if !found {
+			t.Errorf("Expected team %d to be left out, got %v.", id, ids)
+		}
+	}
+	if ids := weakestTeamIDs(map[int][]teamRanking{1: {{ID: 1}, {ID: 2}}, 2: {{ID: 3}, {ID: 4}}}); len(ids) != 0 {
+		t.Errorf("Expected no team left out for pools of the same size, got %v.", ids)
+	}
+}
+
+func TestPerGame(t *testing.T) {
+	if perGame(7, 0) != 0 {
+		t.Errorf("Expected 0 before the first match, got %v.", perGame(7, 0))
+	}
+	if formatPerGame(7, 3) != "2.33" {
+		t.Errorf("Expected 2.33, got %s.", formatPerGame(7, 3))
+	}
+}
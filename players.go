@@ -0,0 +1,229 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	errInvalidGoals  = errors.New("invalid goals")
+	errGoalsMismatch = errors.New("scorer goals do not add up to the score")
+)
+
+// matchGoal is a goal scored for a team. A goal without player is an own goal of the opponent.
+type matchGoal struct {
+	TeamID   int
+	PlayerID sql.NullInt64
+	Minute   sql.NullInt64
+}
+
+type topScorer struct {
+	Rank       int
+	PlayerName string
+	Number     sql.NullInt64
+	// TeamName lists the teams of the player when scorers are counted across tournaments.
+	TeamName    string
+	Goals       int
+	Tournaments int
+}
+
+// matchSheet is a pool or ranking match as needed to enter its scorers.
+type matchSheet struct {
+	Ref          string
+	Label        string
+	ResultStatus string
+	Teams        []goalSheetTeam
+}
+
+type goalSheetTeam struct {
+	ID      int
+	Name    string
+	Score   sql.NullInt64
+	Players []goalSheetPlayer
+	// OwnGoals are the goals scored by the opponents into their own net.
+	OwnGoals int
+}
+
+type goalSheetPlayer struct {
+	player
+	Goals   int
+	Minutes string
+}
+
+// ScorersAllowed tells whether scorers can be entered: the match needs a score which was played on the pitch.
+func (m matchSheet) ScorersAllowed() bool {
+	return (m.ResultStatus == resultPlayed || m.ResultStatus == resultAbandoned) && m.Teams[0].Score.Valid
+}
+
+// loadMatchSheet finds the match of the reference with the rosters of both teams and the goals already entered.
+// The score of a ranking match includes the extra time, but not the penalty shoot-out.
+func loadMatchSheet(db *sql.DB, tournamentID string, ref string) (matchSheet, error) {
+	poolIndex, matchID, key, err := parseMatchRef(ref)
+	if err != nil {
+		return matchSheet{}, err
+	}
+	var sheet matchSheet
+	if key == "" {
+		for _, match := range selectTournamentPoolMatches(db, tournamentID, poolIndex, NullTime{}, NullTime{}) {
+			if match.ID == matchID {
+				sheet = matchSheet{
+					Ref:          ref,
+					Label:        fmt.Sprintf("Poule %s", selectTournamentPool(db, tournamentID, poolIndex).Name),
+					ResultStatus: match.ResultStatus,
+					Teams: []goalSheetTeam{
+						{ID: match.HomeTeamID, Name: match.HomeTeamName, Score: match.HomeTeamGoals},
+						{ID: match.VisitorTeamID, Name: match.VisitorTeamName, Score: match.VisitorTeamGoals},
+					},
+				}
+			}
+		}
+	} else {
+		for _, match := range selectTournamentRankingMatches(db, tournamentID, NullTime{}, NullTime{}) {
+			if match.Key == key && match.ValidTeams {
+				sheet = matchSheet{
+					Ref:          ref,
+					Label:        "Match " + key,
+					ResultStatus: match.ResultStatus,
+					Teams: []goalSheetTeam{
+						{ID: int(match.HomeTeamID.Int64), Name: match.HomeTeamName.String, Score: match.HomeTeamGoals},
+						{ID: int(match.VisitorTeamID.Int64), Name: match.VisitorTeamName.String, Score: match.VisitorTeamGoals},
+					},
+				}
+				if match.HomeTeamExtraTimeGoals.Valid {
+					sheet.Teams[0].Score = match.HomeTeamExtraTimeGoals
+					sheet.Teams[1].Score = match.VisitorTeamExtraTimeGoals
+				}
+			}
+		}
+	}
+	if sheet.Ref == "" {
+		return sheet, fmt.Errorf("match %q not found", ref)
+	}
+	goals := selectMatchGoals(db, tournamentID, ref)
+	for i := range sheet.Teams {
+		team := &sheet.Teams[i]
+		for _, player := range selectTeamPlayers(db, tournamentID, team.ID) {
+			team.Players = append(team.Players, scorerOf(player, goals))
+		}
+		for _, goal := range goals {
+			if goal.TeamID == team.ID && !goal.PlayerID.Valid {
+				team.OwnGoals++
+			}
+		}
+	}
+	return sheet, nil
+}
+
+func scorerOf(p player, goals []matchGoal) goalSheetPlayer {
+	scorer := goalSheetPlayer{player: p}
+	minutes := make([]string, 0)
+	for _, goal := range goals {
+		if goal.PlayerID.Valid && int(goal.PlayerID.Int64) == p.ID {
+			scorer.Goals++
+			if goal.Minute.Valid {
+				minutes = append(minutes, strconv.FormatInt(goal.Minute.Int64, 10))
+			}
+		}
+	}
+	scorer.Minutes = strings.Join(minutes, ", ")
+	return scorer
+}
+
+// readGoals reads the goals entered on the sheet: the number of goals and the minutes, written as "12, 34",
+// of every player, and the own goals of the opponents. Minutes are optional, but when entered every goal needs one.
+func readGoals(sheet matchSheet, formValue func(string) string) ([]matchGoal, error) {
+	goals := make([]matchGoal, 0)
+	for _, team := range sheet.Teams {
+		for _, player := range team.Players {
+			count, err := optionalCount(formValue(fmt.Sprintf("goals_%d", player.ID)))
+			if err != nil {
+				return nil, err
+			}
+			minutes, err := parseMinutes(formValue(fmt.Sprintf("minutes_%d", player.ID)))
+			if err != nil || (len(minutes) > 0 && len(minutes) != count) {
+				return nil, errInvalidGoals
+			}
+			for i := 0; i < count; i++ {
+				goal := matchGoal{TeamID: team.ID, PlayerID: sql.NullInt64{Int64: int64(player.ID), Valid: true}}
+				if len(minutes) > 0 {
+					goal.Minute = sql.NullInt64{Int64: int64(minutes[i]), Valid: true}
+				}
+				goals = append(goals, goal)
+			}
+		}
+		ownGoals, err := optionalCount(formValue(fmt.Sprintf("own_goals_%d", team.ID)))
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < ownGoals; i++ {
+			goals = append(goals, matchGoal{TeamID: team.ID})
+		}
+	}
+	return goals, nil
+}
+
+// validateGoals checks that the scorers of each team add up to its score. No scorers at all is valid,
+// entering them is optional.
+func validateGoals(sheet matchSheet, goals []matchGoal) error {
+	if len(goals) == 0 {
+		return nil
+	}
+	if !sheet.ScorersAllowed() {
+		return errInvalidGoals
+	}
+	for _, team := range sheet.Teams {
+		count := 0
+		for _, goal := range goals {
+			if goal.TeamID == team.ID {
+				count++
+			}
+		}
+		if int64(count) != team.Score.Int64 {
+			return errGoalsMismatch
+		}
+	}
+	return nil
+}
+
+// discardStaleGoals deletes the scorers of a match whose score changed when they no longer add up to it,
+// so that they are entered again rather than counted for the wrong score.
+func discardStaleGoals(db *sql.DB, tournamentID string, ref string) {
+	sheet, err := loadMatchSheet(db, tournamentID, ref)
+	if err != nil {
+		return
+	}
+	if validateGoals(sheet, selectMatchGoals(db, tournamentID, ref)) != nil {
+		saveMatchGoals(db, tournamentID, ref, nil)
+	}
+}
+
+func optionalCount(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 0 {
+		return 0, errInvalidGoals
+	}
+	return count, nil
+}
+
+func parseMinutes(value string) ([]int, error) {
+	slice := make([]int, 0)
+	for _, minute := range strings.Split(value, ",") {
+		minute = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(minute), "'"))
+		if minute == "" {
+			continue
+		}
+		m, err := strconv.Atoi(minute)
+		if err != nil || m < 1 || m > 200 {
+			return nil, errInvalidGoals
+		}
+		slice = append(slice, m)
+	}
+	return slice, nil
+}
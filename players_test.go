@@ -0,0 +1,59 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func testMatchSheet() matchSheet {
+	return matchSheet{
+		Ref:          "pool-1-1",
+		ResultStatus: resultPlayed,
+		Teams: []goalSheetTeam{
+			{ID: 1, Score: nullInt(2), Players: []goalSheetPlayer{{player: player{ID: 10, TeamID: 1}}, {player: player{ID: 11, TeamID: 1}}}},
+			{ID: 2, Score: nullInt(1), Players: []goalSheetPlayer{{player: player{ID: 20, TeamID: 2}}}},
+		},
+	}
+}
+
+func formValues(values map[string]string) func(string) string {
+	return func(name string) string {
+		return values[name]
+	}
+}
+
+func TestReadGoals(t *testing.T) {
+	goals, err := readGoals(testMatchSheet(), formValues(map[string]string{"goals_10": "2", "minutes_10": "12', 80", "own_goals_2": "1"}))
+	if err != nil {
+		t.Fatalf("Expected goals to be read, got %v.", err)
+	}
+	if len(goals) != 3 || goals[1].Minute != nullInt(80) || goals[2].PlayerID.Valid || goals[2].TeamID != 2 {
+		t.Errorf("Expected 2 goals with minutes and an own goal, got %+v.", goals)
+	}
+	if err := validateGoals(testMatchSheet(), goals); err != nil {
+		t.Errorf("Expected goals to match the score, got %v.", err)
+	}
+}
+
+func TestReadGoalsRejectsMissingMinutes(t *testing.T) {
+	_, err := readGoals(testMatchSheet(), formValues(map[string]string{"goals_10": "2", "minutes_10": "12"}))
+	if err != errInvalidGoals {
+		t.Errorf("Expected a minute to be required for every goal, got %v.", err)
+	}
+}
+
+func TestValidateGoals(t *testing.T) {
+	sheet := testMatchSheet()
+	if err := validateGoals(sheet, nil); err != nil {
+		t.Errorf("Expected scorers to be optional, got %v.", err)
+	}
+	goals := []matchGoal{{TeamID: 1, PlayerID: nullInt(10)}, {TeamID: 2, PlayerID: nullInt(20)}}
+	if err := validateGoals(sheet, goals); err != errGoalsMismatch {
+		t.Errorf("Expected a mismatch with the score, got %v.", err)
+	}
+	sheet.ResultStatus = resultHomeForfeit
+	goals = append(goals, matchGoal{TeamID: 1, PlayerID: sql.NullInt64{Int64: 11, Valid: true}})
+	if err := validateGoals(sheet, goals); err != errInvalidGoals {
+		t.Errorf("Expected no scorers for a forfeit, got %v.", err)
+	}
+}
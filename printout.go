@@ -0,0 +1,350 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+const (
+	printMatchSheets    = "match-sheets"
+	printPitchSchedules = "pitch-schedules"
+	printPoolSchedules  = "pool-schedules"
+	printCertificates   = "certificates"
+	printAll            = "all"
+)
+
+var errNothingToPrint = errors.New("nothing to print")
+
+// printoutSource is the live data of a tournament printed on match sheets, schedules and certificates.
+type printoutSource struct {
+	tournament tournament
+	matches    []displayMatch
+	officials  map[string]official
+	pitches    []pitch
+	pools      []rankingViewModel
+	teams      []team
+	ranking    []tournamentFinalRanking
+	// players are the rosters by team, only loaded when printed.
+	players map[int][]player
+}
+
+// printout is a PDF document in the language of the tournament. The core fonts only cover Latin-1, so
+// texts are converted from UTF-8 to the code page of the fonts.
+type printout struct {
+	pdf    *gofpdf.Fpdf
+	tr     func(string) string
+	locale string
+}
+
+func loadPrintoutSource(db *sql.DB, tournament tournament, rosters bool) printoutSource {
+	matches := loadDisplayMatches(db, tournament, tournament.Locale)
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Start.Before(matches[j].Start) })
+	source := printoutSource{
+		tournament: tournament,
+		matches:    matches,
+		officials:  selectMatchOfficials(db, tournament.ID),
+		pitches:    selectTournamentPitches(db, tournament.ID),
+		pools:      loadAllTournamentPoolsRanking(db, tournament.ID),
+		teams:      selectTournamentTeams(db, tournament.ID),
+		ranking:    loadFinalRanking(db, tournament.ID),
+		players:    make(map[int][]player),
+	}
+	if rosters {
+		for _, team := range source.teams {
+			source.players[team.ID] = selectTeamPlayers(db, tournament.ID, team.ID)
+		}
+	}
+	return source
+}
+
+func newPrintout(locale string) *printout {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	return &printout{pdf: pdf, tr: pdf.UnicodeTranslatorFromDescriptor(""), locale: locale}
+}
+
+func (p *printout) t(id string, args ...interface{}) string {
+	return p.tr(translate(p.locale, id, args...))
+}
+
+func (p *printout) title(text string, subtitle string) {
+	p.pdf.SetFont("Helvetica", "B", 18)
+	p.pdf.CellFormat(0, 10, p.tr(text), "", 1, "C", false, 0, "")
+	if subtitle != "" {
+		p.pdf.SetFont("Helvetica", "", 12)
+		p.pdf.CellFormat(0, 7, p.tr(subtitle), "", 1, "C", false, 0, "")
+	}
+	p.pdf.Ln(4)
+}
+
+// row prints a row of cells, widths being in millimeters and the header row being shaded.
+func (p *printout) row(widths []float64, cells []string, height float64, header bool) {
+	if header {
+		p.pdf.SetFont("Helvetica", "B", 10)
+		p.pdf.SetFillColor(220, 220, 220)
+	} else {
+		p.pdf.SetFont("Helvetica", "", 10)
+	}
+	for i, cell := range cells {
+		p.pdf.CellFormat(widths[i], height, p.tr(cell), "1", 0, "C", header, 0, "")
+	}
+	p.pdf.Ln(-1)
+}
+
+// addMatchSheets prints the sheet of the match of the reference, or of every match when the reference is empty.
+func (p *printout) addMatchSheets(source printoutSource, ref string, rosters bool) error {
+	found := false
+	for _, match := range source.matches {
+		if ref == "" || match.Ref == ref {
+			p.matchSheet(source, match, rosters)
+			found = true
+		}
+	}
+	if !found {
+		return errNothingToPrint
+	}
+	return nil
+}
+
+func (p *printout) matchSheet(source printoutSource, match displayMatch, rosters bool) {
+	pdf := p.pdf
+	pdf.AddPage()
+	p.title(translate(p.locale, "match_sheet")+" - "+source.tournament.Name, fmt.Sprintf("%s · %s · %s", match.Label, match.Start.Format("15:04"), translate(p.locale, "pitch", match.PitchName)))
+	if o, ok := source.officials[match.Ref]; ok {
+		pdf.SetFont("Helvetica", "", 12)
+		pdf.CellFormat(0, 7, p.tr(translate(p.locale, "referee")+" : "+o.Name), "", 1, "C", false, 0, "")
+		pdf.Ln(2)
+	}
+	half := 90.0
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(half, 12, p.tr(match.HomeTeamName), "1", 0, "C", false, 0, "")
+	pdf.CellFormat(half, 12, p.tr(match.VisitorTeamName), "1", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(half, 6, p.t("score"), "LR", 0, "C", false, 0, "")
+	pdf.CellFormat(half, 6, p.t("score"), "LR", 1, "C", false, 0, "")
+	pdf.CellFormat(half, 24, "", "LRB", 0, "C", false, 0, "")
+	pdf.CellFormat(half, 24, "", "LRB", 1, "C", false, 0, "")
+	pdf.Ln(6)
+	if rosters {
+		p.rosters(source.players[match.HomeTeamID], source.players[match.VisitorTeamID])
+	}
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(0, 8, p.t("signatures"), "", 1, "L", false, 0, "")
+	third := 60.0
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(third, 6, p.t("referee"), "LTR", 0, "C", false, 0, "")
+	pdf.CellFormat(third, 6, p.tr(translate(p.locale, "captain")+" "+match.HomeTeamName), "LTR", 0, "C", false, 0, "")
+	pdf.CellFormat(third, 6, p.tr(translate(p.locale, "captain")+" "+match.VisitorTeamName), "LTR", 1, "C", false, 0, "")
+	pdf.CellFormat(third, 25, "", "LRB", 0, "C", false, 0, "")
+	pdf.CellFormat(third, 25, "", "LRB", 0, "C", false, 0, "")
+	pdf.CellFormat(third, 25, "", "LRB", 1, "C", false, 0, "")
+}
+
+// rosters prints the players of both teams side by side, with boxes for their goals and cards.
+func (p *printout) rosters(home []player, visitor []player) {
+	widths := []float64{10, 50, 15, 15}
+	header := []string{translate(p.locale, "number_short"), translate(p.locale, "player"), translate(p.locale, "goals"), translate(p.locale, "cards")}
+	count := len(home)
+	if len(visitor) > count {
+		count = len(visitor)
+	}
+	if count == 0 {
+		return
+	}
+	p.pdf.SetFont("Helvetica", "B", 10)
+	p.pdf.SetFillColor(220, 220, 220)
+	for side := 0; side < 2; side++ {
+		for i, cell := range header {
+			p.pdf.CellFormat(widths[i], 6, p.tr(cell), "1", 0, "C", true, 0, "")
+		}
+	}
+	p.pdf.Ln(-1)
+	p.pdf.SetFont("Helvetica", "", 10)
+	for i := 0; i < count; i++ {
+		for _, players := range [][]player{home, visitor} {
+			cells := []string{"", "", "", ""}
+			if i < len(players) {
+				if players[i].Number.Valid {
+					cells[0] = strconv.FormatInt(players[i].Number.Int64, 10)
+				}
+				cells[1] = players[i].Name
+			}
+			for j, cell := range cells {
+				p.pdf.CellFormat(widths[j], 6, p.tr(cell), "1", 0, "L", false, 0, "")
+			}
+		}
+		p.pdf.Ln(-1)
+	}
+	p.pdf.Ln(6)
+}
+
+// addPitchSchedules prints the schedule of the pitch, or of every pitch when pitchID is zero.
+func (p *printout) addPitchSchedules(source printoutSource, pitchID int) error {
+	found := false
+	for _, pitch := range source.pitches {
+		if pitchID != 0 && pitch.ID != pitchID {
+			continue
+		}
+		found = true
+		p.pdf.AddPage()
+		p.title(translate(p.locale, "pitch", pitch.Name), source.tournament.Name)
+		widths := []float64{18, 30, 45, 22, 45, 20}
+		p.row(widths, []string{translate(p.locale, "time"), translate(p.locale, "match"), translate(p.locale, "team"), translate(p.locale, "score"), translate(p.locale, "team"), translate(p.locale, "referee")}, 8, true)
+		for _, match := range source.matches {
+			if match.PitchID != pitch.ID {
+				continue
+			}
+			referee := ""
+			if o, ok := source.officials[match.Ref]; ok {
+				referee = o.Name
+			}
+			p.row(widths, []string{match.Start.Format("15:04"), match.Label, match.HomeTeamName, match.Score, match.VisitorTeamName, referee}, 10, false)
+		}
+	}
+	if !found {
+		return errNothingToPrint
+	}
+	return nil
+}
+
+// addPoolSchedules prints the matches of the pool and its ranking grid, or those of every pool when
+// poolIndex is zero. The grid gives the score of each team against each opponent, next to the current ranking.
+func (p *printout) addPoolSchedules(source printoutSource, poolIndex int) error {
+	found := false
+	for _, pool := range source.pools {
+		if poolIndex != 0 && pool.PoolIndex != poolIndex {
+			continue
+		}
+		found = true
+		p.pdf.AddPage()
+		p.title(translate(p.locale, "pool", pool.PoolName), source.tournament.Name)
+		widths := []float64{18, 30, 55, 22, 55}
+		p.row(widths, []string{translate(p.locale, "time"), translate(p.locale, "pitch", ""), translate(p.locale, "team"), translate(p.locale, "score"), translate(p.locale, "team")}, 8, true)
+		for _, match := range source.matches {
+			if match.PoolIndex != pool.PoolIndex {
+				continue
+			}
+			p.row(widths, []string{match.Start.Format("15:04"), match.PitchName, match.HomeTeamName, match.Score, match.VisitorTeamName}, 9, false)
+		}
+		p.pdf.Ln(8)
+		p.rankingGrid(source, pool)
+	}
+	if !found {
+		return errNothingToPrint
+	}
+	return nil
+}
+
+func (p *printout) rankingGrid(source printoutSource, pool rankingViewModel) {
+	teams := make([]team, 0)
+	for _, team := range source.teams {
+		if team.PoolIndex == pool.PoolIndex {
+			teams = append(teams, team)
+		}
+	}
+	cell := (180.0 - 45 - 30) / float64(len(teams))
+	widths := []float64{45}
+	header := []string{translate(p.locale, "team")}
+	for _, team := range teams {
+		widths = append(widths, cell)
+		header = append(header, team.Name)
+	}
+	widths = append(widths, 15, 15)
+	header = append(header, translate(p.locale, "points_short"), "#")
+	p.pdf.SetFont("Helvetica", "B", 12)
+	p.pdf.CellFormat(0, 8, p.t("pool_ranking", pool.PoolName), "", 1, "L", false, 0, "")
+	p.row(widths, header, 8, true)
+	for _, team := range teams {
+		cells := []string{team.Name}
+		for _, opponent := range teams {
+			cells = append(cells, gridScore(source.matches, team.ID, opponent.ID))
+		}
+		points, rank := "", ""
+		for _, ranking := range pool.TeamRankings {
+			if ranking.ID == team.ID && ranking.Played > 0 {
+				points = strconv.FormatFloat(ranking.Points, 'f', -1, 64)
+				rank = strconv.Itoa(ranking.Rank)
+			}
+		}
+		cells = append(cells, points, rank)
+		p.row(widths, cells, 10, false)
+		x, y := p.pdf.GetXY()
+		for i, opponent := range teams {
+			if opponent.ID == team.ID {
+				left := 15 + 45 + cell*float64(i)
+				p.pdf.SetFillColor(120, 120, 120)
+				p.pdf.Rect(left, y-10, cell, 10, "FD")
+			}
+		}
+		p.pdf.SetXY(x, y)
+	}
+}
+
+// gridScore is the score of the pool match between both teams from the point of view of the first one.
+func gridScore(matches []displayMatch, teamID int, opponentID int) string {
+	for _, match := range matches {
+		if !match.Played || match.PoolIndex == 0 {
+			continue
+		}
+		if match.HomeTeamID == teamID && match.VisitorTeamID == opponentID {
+			return fmt.Sprintf("%d–%d", match.HomeTeamGoals.Int64, match.VisitorTeamGoals.Int64)
+		}
+		if match.HomeTeamID == opponentID && match.VisitorTeamID == teamID {
+			return fmt.Sprintf("%d–%d", match.VisitorTeamGoals.Int64, match.HomeTeamGoals.Int64)
+		}
+	}
+	return ""
+}
+
+// addCertificates prints a certificate for the team of the final rank, or for every ranked team when rank is zero.
+func (p *printout) addCertificates(source printoutSource, rank int) error {
+	found := false
+	for _, ranking := range source.ranking {
+		if !ranking.TeamName.Valid || (rank != 0 && ranking.Rank != rank) {
+			continue
+		}
+		found = true
+		pdf := p.pdf
+		pdf.AddPageFormat("L", gofpdf.SizeType{Wd: 210, Ht: 297})
+		pdf.SetLineWidth(1.5)
+		pdf.Rect(10, 10, 277, 190, "D")
+		pdf.SetLineWidth(0.2)
+		pdf.SetY(40)
+		pdf.SetFont("Helvetica", "B", 40)
+		pdf.CellFormat(0, 20, p.t("certificate"), "", 1, "C", false, 0, "")
+		pdf.SetFont("Helvetica", "", 22)
+		pdf.CellFormat(0, 14, p.tr(source.tournament.Name), "", 1, "C", false, 0, "")
+		pdf.Ln(12)
+		pdf.SetFont("Helvetica", "B", 36)
+		pdf.CellFormat(0, 20, p.tr(ranking.TeamName.String), "", 1, "C", false, 0, "")
+		pdf.Ln(8)
+		pdf.SetFont("Helvetica", "", 26)
+		pdf.CellFormat(0, 14, p.t("final_position", ordinal(p.locale, ranking.Rank)), "", 1, "C", false, 0, "")
+	}
+	if !found {
+		return errNothingToPrint
+	}
+	return nil
+}
+
+// addAll prints every match sheet, schedule and certificate, leaving out what there is nothing to print for.
+func (p *printout) addAll(source printoutSource, rosters bool) error {
+	errs := []error{
+		p.addPitchSchedules(source, 0),
+		p.addPoolSchedules(source, 0),
+		p.addMatchSheets(source, "", rosters),
+		p.addCertificates(source, 0),
+	}
+	for _, err := range errs {
+		if err == nil {
+			return nil
+		}
+	}
+	return errNothingToPrint
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestGridScore(t *testing.T) {
+	matches := []displayMatch{
+		{PoolIndex: 1, HomeTeamID: 1, VisitorTeamID: 2, HomeTeamGoals: nullInt(3), VisitorTeamGoals: nullInt(1), Played: true},
+		{PoolIndex: 1, HomeTeamID: 2, VisitorTeamID: 3},
+	}
+	if score := gridScore(matches, 1, 2); score != "3–1" {
+		t.Errorf("Expected 3–1 for the home team, got %q.", score)
+	}
+	if score := gridScore(matches, 2, 1); score != "1–3" {
+		t.Errorf("Expected 1–3 for the visitor team, got %q.", score)
+	}
+	if score := gridScore(matches, 2, 3); score != "" {
+		t.Errorf("Expected no score for a match not played, got %q.", score)
+	}
+}
+
+func TestPrintoutNothingToPrint(t *testing.T) {
+	source := printoutSource{matches: []displayMatch{{Ref: "pool-1-1", Label: "Poule A"}}}
+	p := newPrintout("fr")
+	if err := p.addMatchSheets(source, "pool-1-2", false); err != errNothingToPrint {
+		t.Errorf("Expected nothing to print for an unknown match, got %v.", err)
+	}
+	if err := p.addCertificates(source, 0); err != errNothingToPrint {
+		t.Errorf("Expected nothing to print without final ranking, got %v.", err)
+	}
+	if err := p.addMatchSheets(source, "pool-1-1", false); err != nil || p.pdf.PageCount() != 1 {
+		t.Errorf("Expected one match sheet, got %v and %d pages.", err, p.pdf.PageCount())
+	}
+}
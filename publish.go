@@ -0,0 +1,132 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+)
+
+var placeholderTeamName = regexp.MustCompile(`^Team [0-9]+$`)
+
+// publishChecklist lists what has to be fixed before a draft tournament can be published.
+func publishChecklist(db *sql.DB, tournamentID string) []checklistItem {
+	tournament := selectTournament(db, tournamentID)
+	teams := selectTournamentTeams(db, tournamentID)
+	pools := selectTournamentPools(db, tournamentID)
+	rankingMatches := selectTournamentRankingMatches(db, tournamentID, NullTime{}, NullTime{})
+	scheduleIssues := validateSchedule(loadScheduledMatches(db, tournamentID), tournamentScheduleSettings(tournament))
+	return []checklistItem{
+		teamNamesChecklistItem(teams),
+		pitchesChecklistItem(db, tournamentID),
+		bracketChecklistItem(pools, teams, rankingMatches),
+		scheduleChecklistItem(scheduleIssues),
+	}
+}
+
+func checklistOK(items []checklistItem) bool {
+	for _, item := range items {
+		if !item.OK() {
+			return false
+		}
+	}
+	return true
+}
+
+func teamNamesChecklistItem(teams []team) checklistItem {
+	item := checklistItem{Label: "Toutes les équipes sont nommées"}
+	for _, team := range teams {
+		if team.Name == "" || placeholderTeamName.MatchString(team.Name) {
+			item.Problems = append(item.Problems, fmt.Sprintf("Équipe %d de la poule %d non nommée", team.ID, team.PoolIndex))
+		}
+	}
+	return item
+}
+
+func pitchesChecklistItem(db *sql.DB, tournamentID string) checklistItem {
+	item := checklistItem{Label: "Tous les matchs ont un terrain"}
+	if len(selectTournamentPitches(db, tournamentID)) == 0 {
+		item.Problems = append(item.Problems, "Aucun terrain")
+	}
+	if count := countMatchesWithoutPitch(db, tournamentID); count > 0 {
+		item.Problems = append(item.Problems, fmt.Sprintf("%d match(s) sans terrain", count))
+	}
+	return item
+}
+
+func bracketChecklistItem(pools []pool, teams []team, matches []rankingMatch) checklistItem {
+	item := checklistItem{Label: "Le tableau des matchs de classement est complet"}
+	poolSizes := make(map[int]int)
+	for _, team := range teams {
+		poolSizes[team.PoolIndex]++
+	}
+	poolNames := make(map[int]string)
+	for _, pool := range pools {
+		poolNames[pool.Index] = pool.Name
+	}
+	keys := make(map[string]bool)
+	for _, match := range matches {
+		keys[match.Key] = true
+	}
+	used := make(map[string]string)
+	checkSlot := func(match rankingMatch, side string, poolIndex sql.NullInt64, poolRank sql.NullInt64, source sql.NullString, sourceWinner sql.NullBool, bestRank sql.NullInt64, bestPosition sql.NullInt64) {
+		var slot string
+		switch {
+		case bestRank.Valid:
+			poolsWithRank := 0
+			for _, size := range poolSizes {
+				if int64(size) >= bestRank.Int64 {
+					poolsWithRank++
+				}
+			}
+			if bestRank.Int64 < 1 || !bestPosition.Valid || bestPosition.Int64 < 1 || bestPosition.Int64 > int64(poolsWithRank) {
+				item.Problems = append(item.Problems, fmt.Sprintf("Match %s : meilleur %d de rang %d invalide (%s)", match.Key, bestPosition.Int64, bestRank.Int64, side))
+				return
+			}
+			slot = fmt.Sprintf("meilleur %d de rang %d", bestPosition.Int64, bestRank.Int64)
+		case poolIndex.Valid:
+			name, ok := poolNames[int(poolIndex.Int64)]
+			if !ok {
+				item.Problems = append(item.Problems, fmt.Sprintf("Match %s : la poule %d (%s) n'existe pas", match.Key, poolIndex.Int64, side))
+				return
+			}
+			if !poolRank.Valid || poolRank.Int64 < 1 || int(poolRank.Int64) > poolSizes[int(poolIndex.Int64)] {
+				item.Problems = append(item.Problems, fmt.Sprintf("Match %s : rang %d de la poule %s invalide (%s)", match.Key, poolRank.Int64, name, side))
+				return
+			}
+			slot = fmt.Sprintf("rang %d de la poule %s", poolRank.Int64, name)
+		case source.Valid:
+			if !keys[source.String] || source.String == match.Key || !sourceWinner.Valid {
+				item.Problems = append(item.Problems, fmt.Sprintf("Match %s : match source %s invalide (%s)", match.Key, source.String, side))
+				return
+			}
+			if sourceWinner.Bool {
+				slot = "gagnant du match " + source.String
+			} else {
+				slot = "perdant du match " + source.String
+			}
+		default:
+			item.Problems = append(item.Problems, fmt.Sprintf("Match %s : équipe non définie (%s)", match.Key, side))
+			return
+		}
+		if other, ok := used[slot]; ok {
+			item.Problems = append(item.Problems, fmt.Sprintf("Match %s : %s déjà utilisé par le match %s", match.Key, slot, other))
+			return
+		}
+		used[slot] = match.Key
+	}
+	for _, match := range matches {
+		checkSlot(match, "domicile", match.HomeTeamPoolIndex, match.HomeTeamPoolRank, match.HomeTeamSourceRankingMatch, match.HomeTeamSourceRankingMatchWinner,
+			match.HomeTeamBestRank, match.HomeTeamBestPosition)
+		checkSlot(match, "visiteur", match.VisitorTeamPoolIndex, match.VisitorTeamPoolRank, match.VisitorTeamSourceRankingMatch, match.VisitorTeamSourceRankingMatchWinner,
+			match.VisitorTeamBestRank, match.VisitorTeamBestPosition)
+	}
+	return item
+}
+
+func scheduleChecklistItem(issues []scheduleIssue) checklistItem {
+	item := checklistItem{Label: "Aucun conflit de planning"}
+	for _, issue := range issues {
+		item.Problems = append(item.Problems, issue.Message)
+	}
+	return item
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type referee struct {
+	ID   int
+	Name string
+}
+
+// official is whoever referees a match: a referee, or a team which does not play at the same time.
+type official struct {
+	RefereeID sql.NullInt64
+	TeamID    sql.NullInt64
+	Name      string
+}
+
+// Key identifies the official in forms, e.g. "referee-3" or "team-5".
+func (o official) Key() string {
+	switch {
+	case o.RefereeID.Valid:
+		return fmt.Sprintf("referee-%d", o.RefereeID.Int64)
+	case o.TeamID.Valid:
+		return fmt.Sprintf("team-%d", o.TeamID.Int64)
+	default:
+		return ""
+	}
+}
+
+// parseOfficialKey reads a key built by official.Key, an empty key meaning no official.
+func parseOfficialKey(key string) (official, error) {
+	if key == "" {
+		return official{}, nil
+	}
+	var o official
+	parts := strings.SplitN(key, "-", 2)
+	id, err := strconv.Atoi(parts[len(parts)-1])
+	if len(parts) != 2 || err != nil {
+		return o, fmt.Errorf("invalid official %q", key)
+	}
+	switch parts[0] {
+	case "referee":
+		o.RefereeID = sql.NullInt64{Int64: int64(id), Valid: true}
+	case "team":
+		o.TeamID = sql.NullInt64{Int64: int64(id), Valid: true}
+	default:
+		return o, fmt.Errorf("invalid official %q", key)
+	}
+	return o, nil
+}
+
+// tournamentOfficials lists the referees then the teams of the tournament which may referee its matches.
+func tournamentOfficials(referees []referee, teams []team) []official {
+	slice := make([]official, 0)
+	for _, r := range referees {
+		slice = append(slice, official{RefereeID: sql.NullInt64{Int64: int64(r.ID), Valid: true}, Name: r.Name})
+	}
+	for _, t := range teams {
+		if !t.Withdrawn {
+			slice = append(slice, official{TeamID: sql.NullInt64{Int64: int64(t.ID), Valid: true}, Name: t.Name})
+		}
+	}
+	return slice
+}
+
+// refereeConflicts reports officials refereeing two matches at the same time, and teams refereeing a match
+// while they play.
+func refereeConflicts(matches []scheduledMatch, officials map[string]official, gameDuration time.Duration) []scheduleIssue {
+	sorted := make([]scheduledMatch, len(matches))
+	copy(sorted, matches)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Start.Before(sorted[j].Start) })
+	issues := make([]scheduleIssue, 0)
+	for i, a := range sorted {
+		o, ok := officials[a.Ref]
+		if !ok {
+			continue
+		}
+		if o.TeamID.Valid && containsTeam(a, int(o.TeamID.Int64)) {
+			issues = append(issues, scheduleIssue{
+				Kind:      issueRefereePlaying,
+				Message:   fmt.Sprintf("%s arbitre son propre match %s", o.Name, a.Label),
+				MatchRefs: []string{a.Ref},
+			})
+		}
+		for j, b := range sorted {
+			if i == j || !matchesOverlap(a, b, gameDuration) {
+				continue
+			}
+			if other, ok := officials[b.Ref]; ok && j > i && other.Key() == o.Key() {
+				issues = append(issues, scheduleIssue{
+					Kind:      issueRefereeDoubleBooking,
+					Message:   fmt.Sprintf("%s arbitre en même temps %s et %s", o.Name, a.Label, b.Label),
+					MatchRefs: []string{a.Ref, b.Ref},
+				})
+			}
+			if o.TeamID.Valid && containsTeam(b, int(o.TeamID.Int64)) {
+				issues = append(issues, scheduleIssue{
+					Kind:      issueRefereePlaying,
+					Message:   fmt.Sprintf("%s arbitre %s pendant qu'elle joue %s", o.Name, a.Label, b.Label),
+					MatchRefs: []string{a.Ref, b.Ref},
+				})
+			}
+		}
+	}
+	return issues
+}
+
+// autoAssignReferees picks a team for every match without official: a team of the same pool for pool matches,
+// any team for ranking matches, which neither plays nor referees at the same time. The team with the fewest
+// duties is picked, so that duties are balanced. It returns the new assignments only.
+func autoAssignReferees(matches []scheduledMatch, officials map[string]official, teams []team, gameDuration time.Duration) map[string]official {
+	sorted := make([]scheduledMatch, len(matches))
+	copy(sorted, matches)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Start.Before(sorted[j].Start) })
+	assigned := make(map[string]official)
+	for ref, o := range officials {
+		assigned[ref] = o
+	}
+	duties := make(map[int]int)
+	for _, o := range officials {
+		if o.TeamID.Valid {
+			duties[int(o.TeamID.Int64)]++
+		}
+	}
+	added := make(map[string]official)
+	for _, match := range sorted {
+		if _, ok := assigned[match.Ref]; ok {
+			continue
+		}
+		var best *team
+		for i := range teams {
+			candidate := teams[i]
+			if candidate.Withdrawn || (match.PoolIndex != 0 && candidate.PoolIndex != match.PoolIndex) {
+				continue
+			}
+			if !teamIdle(sorted, assigned, match, candidate.ID, gameDuration) {
+				continue
+			}
+			if best == nil || duties[candidate.ID] < duties[best.ID] {
+				best = &teams[i]
+			}
+		}
+		if best == nil {
+			continue
+		}
+		o := official{TeamID: sql.NullInt64{Int64: int64(best.ID), Valid: true}, Name: best.Name}
+		assigned[match.Ref] = o
+		added[match.Ref] = o
+		duties[best.ID]++
+	}
+	return added
+}
+
+// teamIdle tells whether the team neither plays nor referees during the match.
+func teamIdle(matches []scheduledMatch, officials map[string]official, match scheduledMatch, teamID int, gameDuration time.Duration) bool {
+	for _, other := range matches {
+		if other.Ref != match.Ref && !matchesOverlap(match, other, gameDuration) {
+			continue
+		}
+		if containsTeam(other, teamID) {
+			return false
+		}
+		if o, ok := officials[other.Ref]; ok && o.TeamID.Valid && int(o.TeamID.Int64) == teamID {
+			return false
+		}
+	}
+	return true
+}
+
+func containsTeam(match scheduledMatch, teamID int) bool {
+	for _, id := range match.TeamIDs {
+		if id == teamID {
+			return true
+		}
+	}
+	return false
+}
+
+// officialMatches lists the matches refereed by the official, in chronological order.
+func officialMatches(matches []scheduledMatch, officials map[string]official, key string) []scheduledMatch {
+	slice := make([]scheduledMatch, 0)
+	for _, match := range matches {
+		if o, ok := officials[match.Ref]; ok && o.Key() == key {
+			slice = append(slice, match)
+		}
+	}
+	sort.SliceStable(slice, func(i, j int) bool { return slice[i].Start.Before(slice[j].Start) })
+	return slice
+}
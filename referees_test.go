@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseOfficialKey(t *testing.T) {
+	o, err := parseOfficialKey("team-5")
+	if err != nil || o.Key() != "team-5" || !o.TeamID.Valid {
+		t.Errorf("Expected team 5, got %+v (%v).", o, err)
+	}
+	for _, key := range []string{"team", "coach-1", "referee-x"} {
+		if _, err := parseOfficialKey(key); err == nil {
+			t.Errorf("Expected %q to be rejected.", key)
+		}
+	}
+}
+
+func TestAutoAssignRefereesPicksIdleTeamsOfThePool(t *testing.T) {
+	matches := []scheduledMatch{
+		poolMatchAt("pool-1-1", 1, "09:00", 1, 1, 2),
+		poolMatchAt("pool-1-2", 1, "09:20", 1, 2, 3),
+		poolMatchAt("pool-1-3", 1, "09:40", 1, 3, 4),
+		poolMatchAt("pool-2-1", 2, "09:00", 2, 5, 6),
+	}
+	teams := []team{{ID: 1, PoolIndex: 1}, {ID: 2, PoolIndex: 1}, {ID: 3, PoolIndex: 1}, {ID: 4, PoolIndex: 1}, {ID: 5, PoolIndex: 2}, {ID: 6, PoolIndex: 2}}
+	assignments := autoAssignReferees(matches, map[string]official{}, teams, 20*time.Minute)
+	expected := map[string]string{"pool-1-1": "team-3", "pool-1-2": "team-1", "pool-1-3": "team-2"}
+	for ref, key := range expected {
+		if assignments[ref].Key() != key {
+			t.Errorf("Expected %s to referee %s, got %q.", key, ref, assignments[ref].Key())
+		}
+	}
+	if _, ok := assignments["pool-2-1"]; ok {
+		t.Errorf("Expected no idle team in pool 2, got %+v.", assignments["pool-2-1"])
+	}
+	if issues := refereeConflicts(matches, assignments, 20*time.Minute); len(issues) != 0 {
+		t.Errorf("Expected no conflict, got %+v.", issues)
+	}
+}
+
+func TestRefereeConflicts(t *testing.T) {
+	matches := []scheduledMatch{
+		poolMatchAt("pool-1-1", 1, "09:00", 1, 1, 2),
+		poolMatchAt("pool-2-1", 2, "09:10", 2, 3, 4),
+	}
+	officials := map[string]official{
+		"pool-1-1": {TeamID: nullInt(3)},
+		"pool-2-1": {TeamID: nullInt(3)},
+	}
+	issues := refereeConflicts(matches, officials, 20*time.Minute)
+	if len(issues) != 3 {
+		t.Errorf("Expected a double booking and 2 matches refereed while playing, got %+v.", issues)
+	}
+}
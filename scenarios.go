@@ -0,0 +1,354 @@
+package main
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+
+	"github.com/thoas/go-funk"
+)
+
+const (
+	scenarioQualified  = "qualified"
+	scenarioEliminated = "eliminated"
+	scenarioOpen       = "still_open"
+)
+
+// maxPoolScenarios bounds the number of outcome combinations enumerated for a pool, the page telling
+// there are too many when more matches are still to be played.
+const maxPoolScenarios = 200000
+
+// scenarioMaxMargin is the largest goal margin enumerated when goal margins are taken into account. The
+// scenarios page says so, a wider margin possibly changing the statuses found.
+const scenarioMaxMargin = 3
+
+// provisionalTeam is the team holding a pool place of a ranking match if the pool ended now.
+type provisionalTeam struct {
+	ID   int
+	Name string
+}
+
+// matchOutcome is a possible score of a match still to be played.
+type matchOutcome struct {
+	HomeTeamGoals    int
+	VisitorTeamGoals int
+}
+
+// rankRange is the best and the worst rank a team can reach, equal when the ranking is fully decided.
+type rankRange struct {
+	Best  int
+	Worst int
+}
+
+// teamNeed is what happens to a team depending on the results of its own remaining matches, e.g. a win
+// against B and a draw against C. Results are message IDs followed by the opponent.
+type teamNeed struct {
+	Results []teamNeedResult
+	Status  string
+}
+
+type teamNeedResult struct {
+	Result   string
+	Opponent string
+}
+
+// teamScenario sums up the scenarios of a team: the ranks it can still reach and, when the pool places
+// going to the main bracket are known, whether it is already qualified or eliminated, or what it needs.
+type teamScenario struct {
+	Team    teamRanking
+	Ranks   rankRange
+	Status  string
+	Needs   []teamNeed
+	ownKeys []string
+}
+
+// scenarioOutcomes are the scores enumerated for every match: a win for either team and a draw when
+// the scoring model allows it, with every margin up to scenarioMaxMargin when margins are asked for.
+func scenarioOutcomes(scoring scoringModel, margins bool) []matchOutcome {
+	maxMargin := 1
+	if margins {
+		maxMargin = scenarioMaxMargin
+	}
+	outcomes := make([]matchOutcome, 0)
+	for margin := maxMargin; margin >= 1; margin-- {
+		outcomes = append(outcomes, matchOutcome{HomeTeamGoals: margin})
+	}
+	if scoring.DrawAllowed() {
+		outcomes = append(outcomes, matchOutcome{})
+	}
+	for margin := 1; margin <= maxMargin; margin++ {
+		outcomes = append(outcomes, matchOutcome{VisitorTeamGoals: margin})
+	}
+	return outcomes
+}
+
+// projectedRanks adds the scores of the remaining matches to the current ranking. With tie-breaks, teams
+// are ordered the way the pool ranking does; without, teams level on points may end either way round.
+func projectedRanks(t tournament, rankings []teamRanking, remaining []poolMatch, scores []matchOutcome, tieBreaks bool) map[int]rankRange {
+	projected := make(map[int]teamRanking)
+	for _, ranking := range rankings {
+		projected[ranking.ID] = ranking
+	}
+	addResult := func(teamID int, goals int, opponentGoals int) {
+		ranking := projected[teamID]
+		switch {
+		case goals > opponentGoals:
+			ranking.Points += t.pointsPerWin
+		case goals == opponentGoals:
+			ranking.Points += t.pointsPerDraw
+		default:
+			ranking.Points += t.pointsPerDefeat
+		}
+		ranking.Points += float64(goals) * t.pointsPerGoal
+		ranking.GoalBalance += goals - opponentGoals
+		projected[teamID] = ranking
+	}
+	for i, match := range remaining {
+		addResult(match.HomeTeamID, scores[i].HomeTeamGoals, scores[i].VisitorTeamGoals)
+		addResult(match.VisitorTeamID, scores[i].VisitorTeamGoals, scores[i].HomeTeamGoals)
+	}
+	ranks := make(map[int]rankRange)
+	if tieBreaks {
+		ordered := make([]teamRanking, 0)
+		for _, ranking := range projected {
+			ordered = append(ordered, ranking)
+		}
+		sort.Slice(ordered, func(i, j int) bool {
+			a, b := ordered[i], ordered[j]
+			if a.Points != b.Points {
+				return a.Points > b.Points
+			}
+			if a.GoalBalance != b.GoalBalance {
+				return a.GoalBalance > b.GoalBalance
+			}
+			if t.FairPlayTieBreak && a.FairPlayPoints != b.FairPlayPoints {
+				return a.FairPlayPoints < b.FairPlayPoints
+			}
+			return a.Name < b.Name
+		})
+		for i, ranking := range ordered {
+			ranks[ranking.ID] = rankRange{Best: i + 1, Worst: i + 1}
+		}
+		return ranks
+	}
+	for id, ranking := range projected {
+		r := rankRange{Best: 1, Worst: 1}
+		for otherID, other := range projected {
+			if otherID == id {
+				continue
+			}
+			if other.Points > ranking.Points {
+				r.Best++
+			}
+			if other.Points >= ranking.Points {
+				r.Worst++
+			}
+		}
+		ranks[id] = r
+	}
+	return ranks
+}
+
+// scenarioMargins tells whether goal margins are enumerated: never for sets, always when goals bring
+// points, since the points of a team then depend on the margins too.
+func scenarioMargins(t tournament, asked bool) bool {
+	if tournamentScoringModel(t).SetsEntry() {
+		return false
+	}
+	return asked || t.pointsPerGoal != 0
+}
+
+// poolScenarios enumerates every outcome of the remaining matches of a pool, the teams in the first
+// qualifyingPlaces places going to the main bracket, and the best teams at bestRanks across pools
+// possibly going too. With margins, goal margins are enumerated and the
+// tie-breaks decide the teams level on points; without, only the results are. It returns false when
+// there are too many scenarios to enumerate.
+func poolScenarios(t tournament, rankings []teamRanking, remaining []poolMatch, qualifyingPlaces int, bestRanks []int, margins bool) ([]teamScenario, bool) {
+	outcomes := scenarioOutcomes(tournamentScoringModel(t), margins)
+	count := 1
+	for range remaining {
+		count *= len(outcomes)
+		if count > maxPoolScenarios {
+			return nil, false
+		}
+	}
+	scenarios := make([]teamScenario, len(rankings))
+	byID := make(map[int]*teamScenario)
+	for i, ranking := range rankings {
+		scenarios[i] = teamScenario{Team: ranking, Ranks: rankRange{Best: len(rankings), Worst: 1}}
+		byID[ranking.ID] = &scenarios[i]
+	}
+	indices := make([]int, len(remaining))
+	scores := make([]matchOutcome, len(remaining))
+	for {
+		for i, index := range indices {
+			scores[i] = outcomes[index]
+		}
+		for id, r := range projectedRanks(t, rankings, remaining, scores, margins) {
+			scenario := byID[id]
+			if r.Best < scenario.Ranks.Best {
+				scenario.Ranks.Best = r.Best
+			}
+			if r.Worst > scenario.Ranks.Worst {
+				scenario.Ranks.Worst = r.Worst
+			}
+			if qualifyingPlaces > 0 {
+				scenario.addNeed(id, remaining, scores, scenarioStatus(r, qualifyingPlaces, bestRanks))
+			}
+		}
+		if !nextScenario(indices, len(outcomes)) {
+			break
+		}
+	}
+	for i := range scenarios {
+		scenarios[i].ownKeys = nil
+		if qualifyingPlaces == 0 {
+			continue
+		}
+		scenarios[i].Status = scenarios[i].Needs[0].Status
+		for _, need := range scenarios[i].Needs {
+			if need.Status != scenarios[i].Status {
+				scenarios[i].Status = scenarioOpen
+			}
+		}
+		if scenarios[i].Status != scenarioOpen {
+			scenarios[i].Needs = nil
+		}
+	}
+	return scenarios, true
+}
+
+// nextScenario moves to the next combination of outcomes, returning false after the last one.
+func nextScenario(indices []int, outcomes int) bool {
+	for i := len(indices) - 1; i >= 0; i-- {
+		indices[i]++
+		if indices[i] < outcomes {
+			return true
+		}
+		indices[i] = 0
+	}
+	return false
+}
+
+// scenarioStatus is the status of a team within the rank range. A team out of the qualifying places is
+// not eliminated while it may finish at a rank whose best teams across pools go to the main bracket, that
+// depending on the other pools.
+func scenarioStatus(r rankRange, qualifyingPlaces int, bestRanks []int) string {
+	switch {
+	case r.Worst <= qualifyingPlaces:
+		return scenarioQualified
+	case r.Best > qualifyingPlaces:
+		for _, rank := range bestRanks {
+			if rank >= r.Best && rank <= r.Worst {
+				return scenarioOpen
+			}
+		}
+		return scenarioEliminated
+	default:
+		return scenarioOpen
+	}
+}
+
+// addNeed records the status of a scenario under the results of the team in its own remaining matches,
+// a status differing between scenarios with the same own results depending on the other results.
+func (s *teamScenario) addNeed(teamID int, remaining []poolMatch, scores []matchOutcome, status string) {
+	results := make([]teamNeedResult, 0)
+	keys := make([]string, 0)
+	for i, match := range remaining {
+		goals, opponentGoals, opponent := scores[i].HomeTeamGoals, scores[i].VisitorTeamGoals, match.VisitorTeamName
+		switch teamID {
+		case match.HomeTeamID:
+		case match.VisitorTeamID:
+			goals, opponentGoals, opponent = opponentGoals, goals, match.HomeTeamName
+		default:
+			continue
+		}
+		result := "draw_against"
+		if goals > opponentGoals {
+			result = "win_against"
+		} else if goals < opponentGoals {
+			result = "defeat_against"
+		}
+		results = append(results, teamNeedResult{Result: result, Opponent: opponent})
+		keys = append(keys, result)
+	}
+	key := strings.Join(keys, ",")
+	for i, ownKey := range s.ownKeys {
+		if ownKey == key {
+			if s.Needs[i].Status != status {
+				s.Needs[i].Status = scenarioOpen
+			}
+			return
+		}
+	}
+	s.ownKeys = append(s.ownKeys, key)
+	s.Needs = append(s.Needs, teamNeed{Results: results, Status: status})
+}
+
+// qualifyingPlaces is the number of places of the pool going to the main bracket, the one deciding the
+// first place, zero when no ranking match of that bracket takes teams from the pool. It also returns the
+// ranks whose best teams across pools go to the main bracket.
+func qualifyingPlaces(poolIndex int, matches []rankingMatch) (int, []int) {
+	brackets := rankingBrackets(matches)
+	bestRanks := make([]int, 0)
+	if len(brackets) == 0 {
+		return 0, bestRanks
+	}
+	places := 0
+	var visit func(node bracketNode)
+	visit = func(node bracketNode) {
+		for _, slot := range []struct{ index, rank sql.NullInt64 }{
+			{node.Match.HomeTeamPoolIndex, node.Match.HomeTeamPoolRank},
+			{node.Match.VisitorTeamPoolIndex, node.Match.VisitorTeamPoolRank},
+		} {
+			if slot.index.Valid && int(slot.index.Int64) == poolIndex && int(slot.rank.Int64) > places {
+				places = int(slot.rank.Int64)
+			}
+		}
+		for _, bestRank := range []sql.NullInt64{node.Match.HomeTeamBestRank, node.Match.VisitorTeamBestRank} {
+			if bestRank.Valid && !funk.ContainsInt(bestRanks, int(bestRank.Int64)) {
+				bestRanks = append(bestRanks, int(bestRank.Int64))
+			}
+		}
+		for _, child := range node.Children {
+			visit(child)
+		}
+	}
+	visit(brackets[0].Root)
+	return places, bestRanks
+}
+
+// provisionalTeams returns the team currently at every rank of the pools which have started but are not
+// over, by pool index and rank, for the placeholders of the ranking matches.
+func provisionalTeams(db *sql.DB, tournamentID string, pools []pool) map[int]map[int]provisionalTeam {
+	teams := make(map[int]map[int]provisionalTeam)
+	for _, pool := range pools {
+		if countPoolMatchesToBePlayed(db, tournamentID, pool.Index) == 0 {
+			continue
+		}
+		rankings := rankPool(db, tournamentID, pool.Index)
+		started := false
+		for _, ranking := range rankings {
+			started = started || ranking.Played > 0
+		}
+		if !started {
+			continue
+		}
+		teams[pool.Index] = make(map[int]provisionalTeam)
+		for _, ranking := range rankings {
+			teams[pool.Index][ranking.Rank] = provisionalTeam{ID: ranking.ID, Name: ranking.Name}
+		}
+	}
+	return teams
+}
+
+// teamProvisionalMatches returns the ranking matches the team would play if its pool ended now.
+func teamProvisionalMatches(teamID int, matches []rankingMatch) []rankingMatch {
+	slice := make([]rankingMatch, 0)
+	for _, match := range matches {
+		if match.HomeTeamProvisional.ID == teamID || match.VisitorTeamProvisional.ID == teamID {
+			slice = append(slice, match)
+		}
+	}
+	return slice
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestPoolScenarios(t *testing.T) {
+	tournament := tournament{pointsPerWin: 3, pointsPerDraw: 1}
+	rankings := []teamRanking{
+		{ID: 1, Name: "Lions", Points: 9},
+		{ID: 2, Name: "Tigers", Points: 4},
+		{ID: 3, Name: "Bears", Points: 3},
+		{ID: 4, Name: "Wolves", Points: 0},
+	}
+	remaining := []poolMatch{
+		{HomeTeamID: 2, HomeTeamName: "Tigers", VisitorTeamID: 3, VisitorTeamName: "Bears"},
+		{HomeTeamID: 1, HomeTeamName: "Lions", VisitorTeamID: 4, VisitorTeamName: "Wolves"},
+	}
+	scenarios, ok := poolScenarios(tournament, rankings, remaining, 2, nil, false)
+	if !ok || len(scenarios) != 4 {
+		t.Fatalf("Expected the scenarios of the 4 teams, got %+v.", scenarios)
+	}
+	if lions := scenarios[0]; lions.Status != scenarioQualified || lions.Ranks != (rankRange{Best: 1, Worst: 1}) {
+		t.Errorf("Expected Lions to be qualified in first place, got %+v.", lions)
+	}
+	if wolves := scenarios[3]; wolves.Status != scenarioEliminated || wolves.Ranks != (rankRange{Best: 3, Worst: 4}) {
+		t.Errorf("Expected Wolves to be eliminated, got %+v.", wolves)
+	}
+	needs := map[int]map[string]string{
+		2: {"win_against": scenarioQualified, "draw_against": scenarioQualified, "defeat_against": scenarioEliminated},
+		3: {"win_against": scenarioQualified, "draw_against": scenarioEliminated, "defeat_against": scenarioEliminated},
+	}
+	for _, scenario := range scenarios[1:3] {
+		if scenario.Status != scenarioOpen || len(scenario.Needs) != 3 {
+			t.Fatalf("Expected %s to depend on their last match, got %+v.", scenario.Team.Name, scenario)
+		}
+		for _, need := range scenario.Needs {
+			if need.Status != needs[scenario.Team.ID][need.Results[0].Result] {
+				t.Errorf("Expected %s to be %s after a %s, got %s.", scenario.Team.Name, needs[scenario.Team.ID][need.Results[0].Result], need.Results[0].Result, need.Status)
+			}
+		}
+	}
+}
+
+func TestProjectedRanksTieBreaks(t *testing.T) {
+	tournament := tournament{pointsPerWin: 3, pointsPerDraw: 1}
+	rankings := []teamRanking{
+		{ID: 1, Name: "Lions", Points: 3, GoalBalance: 2},
+		{ID: 2, Name: "Tigers", Points: 0, GoalBalance: 0},
+	}
+	remaining := []poolMatch{{HomeTeamID: 1, VisitorTeamID: 2}}
+	scores := []matchOutcome{{HomeTeamGoals: 0, VisitorTeamGoals: 3}}
+	if ranks := projectedRanks(tournament, rankings, remaining, scores, false); ranks[1] != (rankRange{Best: 1, Worst: 2}) {
+		t.Errorf("Expected teams level on points to end either way round, got %+v.", ranks)
+	}
+	if ranks := projectedRanks(tournament, rankings, remaining, scores, true); ranks[2] != (rankRange{Best: 1, Worst: 1}) {
+		t.Errorf("Expected Tigers to be first on goal difference, got %+v.", ranks)
+	}
+}
+
+func TestPoolScenariosTooMany(t *testing.T) {
+	remaining := make([]poolMatch, 12)
+	if _, ok := poolScenarios(tournament{}, nil, remaining, 2, nil, false); ok {
+		t.Errorf("Expected too many scenarios for 12 matches.")
+	}
+}
+
+func TestQualifyingPlaces(t *testing.T) {
+	poolSlot := func(key string, homeRank int, visitorRank int) rankingMatch {
+		return rankingMatch{Key: key,
+			HomeTeamPoolIndex: nullInt(1), HomeTeamPoolRank: nullInt(int64(homeRank)),
+			VisitorTeamPoolIndex: nullInt(2), VisitorTeamPoolRank: nullInt(int64(visitorRank))}
+	}
+	semiFinal1, semiFinal2, fifth := poolSlot("SF1", 1, 2), poolSlot("SF2", 2, 1), poolSlot("P5", 3, 3)
+	final := rankingMatch{Key: "F", WinnerFinalRank: nullInt(1), LooserFinalRank: nullInt(2),
+		HomeTeamSourceRankingMatch: sql.NullString{String: "SF1", Valid: true}, HomeTeamSourceRankingMatchWinner: sql.NullBool{Bool: true, Valid: true},
+		VisitorTeamSourceRankingMatch: sql.NullString{String: "SF2", Valid: true}, VisitorTeamSourceRankingMatchWinner: sql.NullBool{Bool: true, Valid: true}}
+	fifth.WinnerFinalRank, fifth.LooserFinalRank = nullInt(5), nullInt(6)
+	if places, _ := qualifyingPlaces(1, []rankingMatch{semiFinal1, semiFinal2, final, fifth}); places != 2 {
+		t.Errorf("Expected the first 2 places to go to the final bracket, got %d.", places)
+	}
+	if places, _ := qualifyingPlaces(3, []rankingMatch{semiFinal1, semiFinal2, final, fifth}); places != 0 {
+		t.Errorf("Expected no qualifying places for a pool out of the bracket, got %d.", places)
+	}
+}
+
+func TestPoolScenariosWithBestRankedSlot(t *testing.T) {
+	quarterFinal := func(key string, visitorTeamBestRank sql.NullInt64) rankingMatch {
+		return rankingMatch{Key: key, HomeTeamPoolIndex: nullInt(1), HomeTeamPoolRank: nullInt(1),
+			VisitorTeamPoolIndex: nullInt(2), VisitorTeamPoolRank: nullInt(2), VisitorTeamBestRank: visitorTeamBestRank}
+	}
+	qf1, qf2 := quarterFinal("QF1", sql.NullInt64{}), quarterFinal("QF2", nullInt(3))
+	semiFinal := rankingMatch{Key: "SF", WinnerFinalRank: nullInt(1), LooserFinalRank: nullInt(2),
+		HomeTeamSourceRankingMatch: sql.NullString{String: "QF1", Valid: true}, HomeTeamSourceRankingMatchWinner: sql.NullBool{Bool: true, Valid: true},
+		VisitorTeamSourceRankingMatch: sql.NullString{String: "QF2", Valid: true}, VisitorTeamSourceRankingMatchWinner: sql.NullBool{Bool: true, Valid: true}}
+	qf2.VisitorTeamPoolIndex, qf2.VisitorTeamPoolRank = sql.NullInt64{}, sql.NullInt64{}
+	places, bestRanks := qualifyingPlaces(1, []rankingMatch{qf1, qf2, semiFinal})
+	if places != 1 || len(bestRanks) != 1 || bestRanks[0] != 3 {
+		t.Fatalf("Expected 1 qualifying place and the best thirds, got %d and %v.", places, bestRanks)
+	}
+
+	tournament := tournament{pointsPerWin: 3, pointsPerDraw: 1}
+	rankings := []teamRanking{
+		{ID: 1, Name: "Lions", Points: 9},
+		{ID: 2, Name: "Tigers", Points: 6},
+		{ID: 3, Name: "Bears", Points: 1},
+		{ID: 4, Name: "Wolves", Points: 0},
+	}
+	remaining := []poolMatch{{HomeTeamID: 3, HomeTeamName: "Bears", VisitorTeamID: 4, VisitorTeamName: "Wolves"}}
+	scenarios, _ := poolScenarios(tournament, rankings, remaining, 2, []int{3}, false)
+	for _, scenario := range scenarios[2:] {
+		if scenario.Status != scenarioOpen {
+			t.Errorf("Expected %s to keep a chance among the best thirds, got %s.", scenario.Team.Name, scenario.Status)
+		}
+	}
+	if tigers := scenarios[1]; tigers.Status != scenarioQualified {
+		t.Errorf("Expected Tigers to be qualified, got %s.", tigers.Status)
+	}
+}
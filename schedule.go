@@ -0,0 +1,430 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/thoas/go-funk"
+)
+
+const (
+	issuePitchDoubleBooking   = "pitch_double_booking"
+	issueTeamDoubleBooking    = "team_double_booking"
+	issueInsufficientRest     = "insufficient_rest"
+	issueDependencyOrder      = "dependency_order"
+	issueOutsideWindow        = "outside_window"
+	issueRefereeDoubleBooking = "referee_double_booking"
+	issueRefereePlaying       = "referee_playing"
+	issuePlayedMatch          = "played_match"
+	issueDayChange            = "day_change"
+)
+
+type scheduledMatch struct {
+	Ref           string
+	Label         string
+	Start         time.Time
+	PitchID       int
+	PitchName     string
+	PoolIndex     int
+	TeamIDs       []int
+	SourcePools   []int
+	SourceMatches []string
+	TeamNames     map[int]string
+	Played        bool
+}
+
+type playingWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+type scheduleSettings struct {
+	GameDuration time.Duration
+	MinRest      time.Duration
+	Windows      []playingWindow
+}
+
+type scheduleIssue struct {
+	Kind      string
+	Message   string
+	MatchRefs []string
+}
+
+func poolMatchRef(poolIndex int, matchID int) string {
+	return fmt.Sprintf("pool-%d-%d", poolIndex, matchID)
+}
+
+func rankingMatchRef(key string) string {
+	return "ranking-" + key
+}
+
+// parseMatchRef splits a reference built by poolMatchRef or rankingMatchRef.
+func parseMatchRef(ref string) (poolIndex int, matchID int, key string, err error) {
+	if strings.HasPrefix(ref, "ranking-") {
+		return 0, 0, strings.TrimPrefix(ref, "ranking-"), nil
+	}
+	_, err = fmt.Sscanf(ref, "pool-%d-%d", &poolIndex, &matchID)
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("invalid match reference %q", ref)
+	}
+	return poolIndex, matchID, "", nil
+}
+
+// parsePlayingWindows parses windows written as "09:00-12:00,13:30-18:00".
+// An empty string means matches can be scheduled at any time.
+func parsePlayingWindows(windows string) ([]playingWindow, error) {
+	slice := make([]playingWindow, 0)
+	for _, window := range strings.Split(windows, ",") {
+		window = strings.TrimSpace(window)
+		if window == "" {
+			continue
+		}
+		bounds := strings.Split(window, "-")
+		if len(bounds) != 2 {
+			return nil, fmt.Errorf("invalid playing window %q", window)
+		}
+		start, err := time.Parse(timeFormat, strings.TrimSpace(bounds[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid playing window %q", window)
+		}
+		end, err := time.Parse(timeFormat, strings.TrimSpace(bounds[1]))
+		if err != nil || !end.After(start) {
+			return nil, fmt.Errorf("invalid playing window %q", window)
+		}
+		slice = append(slice, playingWindow{Start: start, End: end})
+	}
+	return slice, nil
+}
+
+// timeOfDay drops the date of a scheduled time, to compare it with the playing windows.
+func timeOfDay(t time.Time) time.Time {
+	return parseTime(formatTime(t))
+}
+
+// parseScheduleTime parses a time entered as 2006-01-02 15:04, or as 15:04 on the given day.
+func parseScheduleTime(value string, day time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(scheduledAtFormat, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(timeFormat, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q", value)
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC), nil
+}
+
+func tournamentScheduleSettings(t tournament) scheduleSettings {
+	windows, _ := parsePlayingWindows(t.PlayingWindows)
+	return scheduleSettings{
+		GameDuration: time.Duration(t.GameDurationMinutes) * time.Minute,
+		MinRest:      time.Duration(t.MinRestMinutes) * time.Minute,
+		Windows:      windows,
+	}
+}
+
+// loadScheduledMatches gathers the pool and ranking matches of a tournament in the shape expected by validateSchedule.
+func loadScheduledMatches(db *sql.DB, tournamentID string) []scheduledMatch {
+	pools := selectTournamentPools(db, tournamentID)
+	poolNames := make(map[int]string)
+	for _, pool := range pools {
+		poolNames[pool.Index] = pool.Name
+	}
+	matches := make([]scheduledMatch, 0)
+	for _, match := range selectAllTournamentPoolMatches(db, tournamentID) {
+		matches = append(matches, scheduledMatch{
+			Ref:       poolMatchRef(match.PoolIndex, match.ID),
+			Label:     fmt.Sprintf("Poule %s : %s - %s", poolNames[match.PoolIndex], match.HomeTeamName, match.VisitorTeamName),
+			Start:     match.ScheduledAt,
+			PitchID:   match.PitchID,
+			PitchName: match.PitchName,
+			PoolIndex: match.PoolIndex,
+			TeamIDs:   []int{match.HomeTeamID, match.VisitorTeamID},
+			TeamNames: map[int]string{
+				match.HomeTeamID:    match.HomeTeamName,
+				match.VisitorTeamID: match.VisitorTeamName,
+			},
+			Played: match.HomeTeamGoals.Valid,
+		})
+	}
+	for _, match := range selectTournamentRankingMatches(db, tournamentID, NullTime{}, NullTime{}) {
+		scheduled := scheduledMatch{
+			Ref:       rankingMatchRef(match.Key),
+			Label:     "Match " + match.Key,
+			Start:     match.ScheduledAt,
+			PitchID:   match.PitchID,
+			PitchName: match.PitchName,
+			TeamNames: make(map[int]string),
+			Played:    match.HomeTeamGoals.Valid,
+		}
+		sides := []struct {
+			teamID   sql.NullInt64
+			teamName sql.NullString
+			source   sql.NullString
+		}{
+			{match.HomeTeamID, match.HomeTeamName, match.HomeTeamSourceRankingMatch},
+			{match.VisitorTeamID, match.VisitorTeamName, match.VisitorTeamSourceRankingMatch},
+		}
+		scheduled.SourcePools = rankingMatchSourcePools(match, pools)
+		for _, side := range sides {
+			if side.teamID.Valid && side.teamID.Int64 != 0 {
+				scheduled.TeamIDs = append(scheduled.TeamIDs, int(side.teamID.Int64))
+				scheduled.TeamNames[int(side.teamID.Int64)] = side.teamName.String
+			}
+			if side.source.Valid {
+				scheduled.SourceMatches = append(scheduled.SourceMatches, rankingMatchRef(side.source.String))
+			}
+		}
+		matches = append(matches, scheduled)
+	}
+	return matches
+}
+
+// rankingMatchSourcePools returns the pools the teams of a ranking match come from. A team best at a rank
+// across pools may come from any pool, so every pool is a source of the match.
+func rankingMatchSourcePools(match rankingMatch, pools []pool) []int {
+	sourcePools := make([]int, 0)
+	if match.HomeTeamBestRank.Valid || match.VisitorTeamBestRank.Valid {
+		for _, pool := range pools {
+			sourcePools = append(sourcePools, pool.Index)
+		}
+		return sourcePools
+	}
+	for _, poolIndex := range []sql.NullInt64{match.HomeTeamPoolIndex, match.VisitorTeamPoolIndex} {
+		if poolIndex.Valid {
+			sourcePools = append(sourcePools, int(poolIndex.Int64))
+		}
+	}
+	return sourcePools
+}
+
+// validateSchedule reports double bookings of pitches and teams, teams without enough rest between two
+// matches, ranking matches starting before the matches they depend on are over, and matches outside the
+// playing windows.
+func validateSchedule(matches []scheduledMatch, settings scheduleSettings) []scheduleIssue {
+	sorted := make([]scheduledMatch, len(matches))
+	copy(sorted, matches)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Start.Before(sorted[j].Start) })
+
+	issues := make([]scheduleIssue, 0)
+	end := func(match scheduledMatch) time.Time { return match.Start.Add(settings.GameDuration) }
+	overlaps := func(a scheduledMatch, b scheduledMatch) bool {
+		return matchesOverlap(a, b, settings.GameDuration)
+	}
+
+	for i := 0; i < len(sorted); i++ {
+		for j := i + 1; j < len(sorted); j++ {
+			a, b := sorted[i], sorted[j]
+			if !overlaps(a, b) {
+				continue
+			}
+			if a.PitchID == b.PitchID {
+				issues = append(issues, scheduleIssue{
+					Kind:      issuePitchDoubleBooking,
+					Message:   fmt.Sprintf("Terrain %s occupé à %s par %s et %s", a.PitchName, formatTime(b.Start), a.Label, b.Label),
+					MatchRefs: []string{a.Ref, b.Ref},
+				})
+			}
+			for _, teamID := range a.TeamIDs {
+				if funk.ContainsInt(b.TeamIDs, teamID) {
+					issues = append(issues, scheduleIssue{
+						Kind:      issueTeamDoubleBooking,
+						Message:   fmt.Sprintf("%s joue en même temps %s et %s", a.TeamNames[teamID], a.Label, b.Label),
+						MatchRefs: []string{a.Ref, b.Ref},
+					})
+				}
+			}
+		}
+	}
+
+	lastMatchByTeam := make(map[int]scheduledMatch)
+	for _, match := range sorted {
+		for _, teamID := range match.TeamIDs {
+			previous, ok := lastMatchByTeam[teamID]
+			lastMatchByTeam[teamID] = match
+			if !ok || overlaps(previous, match) {
+				continue
+			}
+			rest := match.Start.Sub(end(previous))
+			if rest < settings.MinRest {
+				issues = append(issues, scheduleIssue{
+					Kind: issueInsufficientRest,
+					Message: fmt.Sprintf("%s n'a que %d minutes de repos entre %s et %s (minimum %d)",
+						match.TeamNames[teamID], int(rest.Minutes()), previous.Label, match.Label, int(settings.MinRest.Minutes())),
+					MatchRefs: []string{previous.Ref, match.Ref},
+				})
+			}
+		}
+	}
+
+	for _, match := range sorted {
+		for _, source := range sorted {
+			dependsOn := funk.ContainsString(match.SourceMatches, source.Ref) ||
+				(source.PoolIndex != 0 && funk.ContainsInt(match.SourcePools, source.PoolIndex))
+			if dependsOn && match.Start.Before(end(source)) {
+				issues = append(issues, scheduleIssue{
+					Kind:      issueDependencyOrder,
+					Message:   fmt.Sprintf("%s commence à %s avant la fin de %s", match.Label, formatTime(match.Start), source.Label),
+					MatchRefs: []string{source.Ref, match.Ref},
+				})
+			}
+		}
+	}
+
+	if len(settings.Windows) > 0 {
+		for _, match := range sorted {
+			inWindow := false
+			start := timeOfDay(match.Start)
+			for _, window := range settings.Windows {
+				if !start.Before(window.Start) && !start.Add(settings.GameDuration).After(window.End) {
+					inWindow = true
+					break
+				}
+			}
+			if !inWindow {
+				issues = append(issues, scheduleIssue{
+					Kind:      issueOutsideWindow,
+					Message:   fmt.Sprintf("%s à %s est en dehors des plages de jeu", match.Label, formatTime(match.Start)),
+					MatchRefs: []string{match.Ref},
+				})
+			}
+		}
+	}
+	return issues
+}
+
+// matchesOverlap tells whether two matches of the given duration are played at the same time.
+func matchesOverlap(a scheduledMatch, b scheduledMatch, gameDuration time.Duration) bool {
+	if a.Start.Equal(b.Start) {
+		return true
+	}
+	return a.Start.Before(b.Start.Add(gameDuration)) && b.Start.Before(a.Start.Add(gameDuration))
+}
+
+// moveMatch moves the match to the given time and pitch. When another match already uses this slot,
+// both matches are swapped. It returns the updated schedule and the matches which changed.
+func moveMatch(matches []scheduledMatch, ref string, start time.Time, pitchID int) ([]scheduledMatch, []scheduledMatch) {
+	moved := make([]scheduledMatch, len(matches))
+	copy(moved, matches)
+	source := -1
+	target := -1
+	for i, match := range moved {
+		if match.Ref == ref {
+			source = i
+		} else if match.Start.Equal(start) && match.PitchID == pitchID && target == -1 {
+			target = i
+		}
+	}
+	if source == -1 {
+		return moved, nil
+	}
+	changed := make([]scheduledMatch, 0)
+	if target != -1 {
+		moved[target].Start = moved[source].Start
+		moved[target].PitchID = moved[source].PitchID
+		changed = append(changed, moved[target])
+	}
+	moved[source].Start = start
+	moved[source].PitchID = pitchID
+	changed = append(changed, moved[source])
+	return moved, changed
+}
+
+// shiftMatches delays (or advances with negative minutes) every match without score scheduled from the
+// given time. It returns the updated schedule and the matches which changed.
+func shiftMatches(matches []scheduledMatch, from time.Time, minutes int) ([]scheduledMatch, []scheduledMatch) {
+	shifted := make([]scheduledMatch, len(matches))
+	copy(shifted, matches)
+	changed := make([]scheduledMatch, 0)
+	for i, match := range shifted {
+		if match.Played || match.Start.Before(from) {
+			continue
+		}
+		shifted[i].Start = match.Start.Add(time.Duration(minutes) * time.Minute)
+		changed = append(changed, shifted[i])
+	}
+	return shifted, changed
+}
+
+// rejectedChanges reports the played matches among the changed ones, their score being tied to their
+// slot, and the matches a shift would move to another day.
+func rejectedChanges(matches []scheduledMatch, changed []scheduledMatch, shift bool) []scheduleIssue {
+	issues := make([]scheduleIssue, 0)
+	for _, match := range changed {
+		for _, original := range matches {
+			if original.Ref != match.Ref {
+				continue
+			}
+			if original.Played {
+				issues = append(issues, scheduleIssue{
+					Kind:      issuePlayedMatch,
+					Message:   fmt.Sprintf("%s est déjà joué", match.Label),
+					MatchRefs: []string{match.Ref},
+				})
+			}
+			if shift && original.Start.Format("2006-01-02") != match.Start.Format("2006-01-02") {
+				issues = append(issues, scheduleIssue{
+					Kind:      issueDayChange,
+					Message:   fmt.Sprintf("%s passerait de %s à %s, un autre jour", match.Label, formatScheduledAt(original.Start), formatScheduledAt(match.Start)),
+					MatchRefs: []string{match.Ref},
+				})
+			}
+		}
+	}
+	return issues
+}
+
+// newConflicts returns the double bookings found in after which were not already in before.
+func newConflicts(before []scheduleIssue, after []scheduleIssue) []scheduleIssue {
+	existing := make(map[string]bool)
+	for _, issue := range before {
+		existing[issue.Message] = true
+	}
+	conflicts := make([]scheduleIssue, 0)
+	for _, issue := range after {
+		doubleBooking := issue.Kind == issuePitchDoubleBooking || issue.Kind == issueTeamDoubleBooking
+		if doubleBooking && !existing[issue.Message] {
+			conflicts = append(conflicts, issue)
+		}
+	}
+	return conflicts
+}
+
+type scheduleCell struct {
+	PitchID int
+	Matches []scheduledMatch
+}
+
+type scheduleSlot struct {
+	Time  time.Time
+	Cells []scheduleCell
+}
+
+// scheduleGrid lays the matches out in a grid of time slots by pitches.
+func scheduleGrid(pitches []pitch, matches []scheduledMatch) []scheduleSlot {
+	times := make([]time.Time, 0)
+	for _, match := range matches {
+		if !funk.Contains(times, match.Start) {
+			times = append(times, match.Start)
+		}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	slots := make([]scheduleSlot, 0)
+	for _, slotTime := range times {
+		slot := scheduleSlot{Time: slotTime}
+		for _, pitch := range pitches {
+			cell := scheduleCell{PitchID: pitch.ID}
+			for _, match := range matches {
+				if match.Start.Equal(slotTime) && match.PitchID == pitch.ID {
+					cell.Matches = append(cell.Matches, match)
+				}
+			}
+			slot.Cells = append(slot.Cells, cell)
+		}
+		slots = append(slots, slot)
+	}
+	return slots
+}
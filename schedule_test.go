@@ -0,0 +1,234 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateScheduleWithoutIssue(t *testing.T) {
+	matches := []scheduledMatch{
+		poolMatchAt("pool-1-1", 1, "09:00", 1, 1, 2),
+		poolMatchAt("pool-1-2", 1, "09:30", 1, 3, 4),
+		poolMatchAt("pool-1-3", 1, "10:00", 1, 1, 3),
+		rankingMatchAt("ranking-F", "11:00", 1, []int{1}, nil),
+	}
+	issues := validateSchedule(matches, scheduleSettings{GameDuration: 20 * time.Minute, MinRest: 10 * time.Minute})
+	if len(issues) != 0 {
+		t.Errorf("Expected no issue, got %v.", issues)
+	}
+}
+
+func TestValidateScheduleDoubleBookings(t *testing.T) {
+	matches := []scheduledMatch{
+		poolMatchAt("pool-1-1", 1, "09:00", 1, 1, 2),
+		poolMatchAt("pool-2-1", 2, "09:10", 1, 3, 4),
+		poolMatchAt("pool-1-2", 1, "09:00", 2, 1, 5),
+	}
+	issues := validateSchedule(matches, scheduleSettings{GameDuration: 20 * time.Minute})
+	expectIssues(t, issues, issuePitchDoubleBooking, 1)
+	expectIssues(t, issues, issueTeamDoubleBooking, 1)
+}
+
+func TestValidateScheduleInsufficientRest(t *testing.T) {
+	matches := []scheduledMatch{
+		poolMatchAt("pool-1-1", 1, "09:00", 1, 1, 2),
+		poolMatchAt("pool-1-2", 1, "09:25", 1, 1, 3),
+	}
+	issues := validateSchedule(matches, scheduleSettings{GameDuration: 20 * time.Minute, MinRest: 10 * time.Minute})
+	expectIssues(t, issues, issueInsufficientRest, 1)
+}
+
+func TestValidateScheduleDependencyOrder(t *testing.T) {
+	matches := []scheduledMatch{
+		poolMatchAt("pool-1-1", 1, "09:00", 1, 1, 2),
+		rankingMatchAt("ranking-SF1", "09:10", 2, nil, []int{1}),
+		rankingMatchAt("ranking-F", "09:30", 2, nil, nil, "ranking-SF1"),
+	}
+	issues := validateSchedule(matches, scheduleSettings{GameDuration: 30 * time.Minute})
+	expectIssues(t, issues, issueDependencyOrder, 2)
+}
+
+func TestValidateScheduleBestRankedDependsOnEveryPool(t *testing.T) {
+	pools := []pool{{Index: 1, Name: "A"}, {Index: 2, Name: "B"}, {Index: 3, Name: "C"}}
+	quarterFinal := rankingMatch{HomeTeamPoolIndex: nullInt(1), HomeTeamPoolRank: nullInt(1), VisitorTeamBestRank: nullInt(3), VisitorTeamBestPosition: nullInt(2)}
+	sourcePools := rankingMatchSourcePools(quarterFinal, pools)
+	if len(sourcePools) != 3 {
+		t.Errorf("Expected every pool to be a source of a best-ranked slot, got %v.", sourcePools)
+	}
+	matches := []scheduledMatch{
+		poolMatchAt("pool-3-1", 3, "10:00", 1, 5, 6),
+		rankingMatchAt("ranking-QF1", "09:30", 2, nil, sourcePools),
+	}
+	issues := validateSchedule(matches, scheduleSettings{GameDuration: 20 * time.Minute})
+	expectIssues(t, issues, issueDependencyOrder, 1)
+}
+
+func TestValidateScheduleOutsidePlayingWindows(t *testing.T) {
+	windows, err := parsePlayingWindows("09:00-12:00, 13:30-18:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches := []scheduledMatch{
+		poolMatchAt("pool-1-1", 1, "09:00", 1, 1, 2),
+		poolMatchAt("pool-1-2", 1, "11:50", 1, 3, 4),
+		poolMatchAt("pool-1-3", 1, "13:30", 1, 1, 3),
+	}
+	issues := validateSchedule(matches, scheduleSettings{GameDuration: 20 * time.Minute, Windows: windows})
+	expectIssues(t, issues, issueOutsideWindow, 1)
+	matches = append(matches, poolMatchAt("pool-1-4", 1, "2024-06-16 09:00", 1, 2, 4), poolMatchAt("pool-1-5", 1, "2024-06-16 08:40", 1, 2, 3))
+	issues = validateSchedule(matches, scheduleSettings{GameDuration: 20 * time.Minute, Windows: windows})
+	expectIssues(t, issues, issueOutsideWindow, 2)
+}
+
+func TestParseScheduleTime(t *testing.T) {
+	day := parseScheduledAt("2024-06-15 00:00")
+	if at, err := parseScheduleTime("09:30", day); err != nil || formatScheduledAt(at) != "2024-06-15 09:30" {
+		t.Errorf("Expected a time of the given day, got %v (%v).", at, err)
+	}
+	if at, err := parseScheduleTime("2024-06-16 08:00", day); err != nil || formatScheduledAt(at) != "2024-06-16 08:00" {
+		t.Errorf("Expected the given date, got %v (%v).", at, err)
+	}
+	if _, err := parseScheduleTime("9h30", day); err == nil {
+		t.Errorf("Expected an error for an invalid time.")
+	}
+}
+
+func TestParsePlayingWindowsRejectsInvalidWindows(t *testing.T) {
+	for _, windows := range []string{"09:00", "12:00-09:00", "9h-12h"} {
+		if _, err := parsePlayingWindows(windows); err == nil {
+			t.Errorf("Expected %q to be rejected.", windows)
+		}
+	}
+}
+
+// scheduledOn is a time of the first day of the tournament, or a time given with its date.
+func scheduledOn(scheduledAt string) time.Time {
+	t, _ := parseScheduleTime(scheduledAt, parseScheduledAt("2024-06-15 00:00"))
+	return t
+}
+
+func poolMatchAt(ref string, poolIndex int, scheduledAt string, pitchID int, homeTeamID int, visitorTeamID int) scheduledMatch {
+	return scheduledMatch{
+		Ref:       ref,
+		Label:     ref,
+		Start:     scheduledOn(scheduledAt),
+		PitchID:   pitchID,
+		PoolIndex: poolIndex,
+		TeamIDs:   []int{homeTeamID, visitorTeamID},
+	}
+}
+
+func rankingMatchAt(ref string, scheduledAt string, pitchID int, teamIDs []int, sourcePools []int, sourceMatches ...string) scheduledMatch {
+	return scheduledMatch{
+		Ref:           ref,
+		Label:         ref,
+		Start:         scheduledOn(scheduledAt),
+		PitchID:       pitchID,
+		TeamIDs:       teamIDs,
+		SourcePools:   sourcePools,
+		SourceMatches: sourceMatches,
+	}
+}
+
+func expectIssues(t *testing.T, issues []scheduleIssue, kind string, expected int) {
+	count := 0
+	for _, issue := range issues {
+		if issue.Kind == kind {
+			count++
+		}
+	}
+	if count != expected {
+		t.Errorf("Expected %d %s issue(s), got %d in %v.", expected, kind, count, issues)
+	}
+}
+
+func TestMoveMatchToFreeSlot(t *testing.T) {
+	matches := []scheduledMatch{
+		poolMatchAt("pool-1-1", 1, "09:00", 1, 1, 2),
+		poolMatchAt("pool-1-2", 1, "09:30", 1, 3, 4),
+	}
+	moved, changed := moveMatch(matches, "pool-1-2", scheduledOn("09:00"), 2)
+	if len(changed) != 1 || changed[0].Ref != "pool-1-2" {
+		t.Errorf("Expected only pool-1-2 to change, got %v.", changed)
+	}
+	if formatTime(moved[1].Start) != "09:00" || moved[1].PitchID != 2 {
+		t.Errorf("Expected pool-1-2 at 09:00 on pitch 2, got %s on pitch %d.", formatTime(moved[1].Start), moved[1].PitchID)
+	}
+	if formatTime(matches[1].Start) != "09:30" {
+		t.Errorf("Expected the original schedule to be left untouched.")
+	}
+}
+
+func TestMoveMatchSwapsWithOccupiedSlot(t *testing.T) {
+	matches := []scheduledMatch{
+		poolMatchAt("pool-1-1", 1, "09:00", 1, 1, 2),
+		poolMatchAt("pool-1-2", 1, "09:30", 2, 3, 4),
+	}
+	moved, changed := moveMatch(matches, "pool-1-2", scheduledOn("09:00"), 1)
+	if len(changed) != 2 {
+		t.Errorf("Expected both matches to change, got %v.", changed)
+	}
+	if formatTime(moved[0].Start) != "09:30" || moved[0].PitchID != 2 {
+		t.Errorf("Expected pool-1-1 at 09:30 on pitch 2, got %s on pitch %d.", formatTime(moved[0].Start), moved[0].PitchID)
+	}
+	if formatTime(moved[1].Start) != "09:00" || moved[1].PitchID != 1 {
+		t.Errorf("Expected pool-1-2 at 09:00 on pitch 1, got %s on pitch %d.", formatTime(moved[1].Start), moved[1].PitchID)
+	}
+}
+
+func TestMovePlayedMatchIsRejected(t *testing.T) {
+	matches := []scheduledMatch{
+		poolMatchAt("pool-1-1", 1, "09:00", 1, 1, 2),
+		poolMatchAt("pool-1-2", 1, "09:30", 2, 3, 4),
+	}
+	matches[0].Played = true
+	_, changed := moveMatch(matches, "pool-1-2", scheduledOn("09:00"), 1)
+	issues := rejectedChanges(matches, changed, false)
+	if len(issues) != 1 || issues[0].Kind != issuePlayedMatch || issues[0].MatchRefs[0] != "pool-1-1" {
+		t.Errorf("Expected the swap with played pool-1-1 to be rejected, got %v.", issues)
+	}
+}
+
+func TestShiftMatchesSkipsPlayedAndEarlierMatches(t *testing.T) {
+	matches := []scheduledMatch{
+		poolMatchAt("pool-1-1", 1, "09:00", 1, 1, 2),
+		poolMatchAt("pool-1-2", 1, "09:30", 1, 3, 4),
+		poolMatchAt("pool-1-3", 1, "10:00", 1, 1, 3),
+	}
+	matches[1].Played = true
+	shifted, changed := shiftMatches(matches, scheduledOn("09:15"), 15)
+	if len(changed) != 1 || changed[0].Ref != "pool-1-3" {
+		t.Errorf("Expected only pool-1-3 to be shifted, got %v.", changed)
+	}
+	if formatTime(shifted[2].Start) != "10:15" || formatTime(shifted[0].Start) != "09:00" {
+		t.Errorf("Expected pool-1-3 at 10:15 and pool-1-1 left at 09:00, got %v.", shifted)
+	}
+}
+
+func TestShiftAcrossMidnightIsRejected(t *testing.T) {
+	matches := []scheduledMatch{
+		poolMatchAt("pool-1-1", 1, "23:00", 1, 1, 2),
+		poolMatchAt("pool-1-2", 1, "23:50", 1, 3, 4),
+	}
+	_, changed := shiftMatches(matches, time.Time{}, 30)
+	issues := rejectedChanges(matches, changed, true)
+	if len(issues) != 1 || issues[0].Kind != issueDayChange || issues[0].MatchRefs[0] != "pool-1-2" {
+		t.Errorf("Expected pool-1-2 moving to the next day to be rejected, got %v.", issues)
+	}
+	if len(rejectedChanges(matches, changed, false)) != 0 {
+		t.Errorf("Expected a move to another day to be allowed.")
+	}
+}
+
+func TestNewConflictsIgnoresExistingOnes(t *testing.T) {
+	before := []scheduleIssue{{Kind: issuePitchDoubleBooking, Message: "a"}}
+	after := []scheduleIssue{
+		{Kind: issuePitchDoubleBooking, Message: "a"},
+		{Kind: issueTeamDoubleBooking, Message: "b"},
+		{Kind: issueInsufficientRest, Message: "c"},
+	}
+	conflicts := newConflicts(before, after)
+	if len(conflicts) != 1 || conflicts[0].Message != "b" {
+		t.Errorf("Expected only conflict b, got %v.", conflicts)
+	}
+}
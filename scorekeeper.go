@@ -0,0 +1,87 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+	"strconv"
+)
+
+// scorekeeperPlayedMatches is the number of played matches kept under the next ones, so that the latest
+// scores can still be corrected from the pitch.
+const scorekeeperPlayedMatches = 3
+
+var errUnknownMatch = errors.New("unknown match")
+
+// scoreSubmission is a score posted by the scorekeeper view. The submission ID is generated by the phone,
+// so that a submission replayed from its offline queue is only applied once.
+type scoreSubmission struct {
+	SubmissionID     string `json:"submissionId"`
+	Ref              string `json:"ref"`
+	HomeTeamGoals    int    `json:"homeTeamGoals"`
+	VisitorTeamGoals int    `json:"visitorTeamGoals"`
+	Sets             string `json:"sets"`
+}
+
+func (s scoreSubmission) Valid() bool {
+	return s.SubmissionID != "" && len(s.SubmissionID) <= 64 && s.Ref != ""
+}
+
+// scorekeeperMatches returns the matches of the pitch still to be played in order, then the latest played ones.
+func scorekeeperMatches(matches []displayMatch, pitchID int) (next []displayMatch, played []displayMatch) {
+	next, played = make([]displayMatch, 0), make([]displayMatch, 0)
+	for _, match := range matches {
+		switch {
+		case match.PitchID != pitchID:
+		case match.Played:
+			played = append(played, match)
+		default:
+			next = append(next, match)
+		}
+	}
+	sort.SliceStable(next, func(i, j int) bool { return next[i].Start.Before(next[j].Start) })
+	sort.SliceStable(played, func(i, j int) bool { return played[i].Start.After(played[j].Start) })
+	if len(played) > scorekeeperPlayedMatches {
+		played = played[:scorekeeperPlayedMatches]
+	}
+	return next, played
+}
+
+// findScoreableMatch returns the match of the tournament, provided both its teams are known.
+func findScoreableMatch(db *sql.DB, tournament tournament, ref string) (displayMatch, error) {
+	for _, match := range loadDisplayMatches(db, tournament, tournament.Locale) {
+		if match.Ref == ref && match.HomeTeamID != 0 && match.VisitorTeamID != 0 {
+			return match, nil
+		}
+	}
+	return displayMatch{}, errUnknownMatch
+}
+
+// applyScoreSubmission saves the score the way the admin score forms do. Ranking matches need their two
+// teams and a winner, draws being settled by extra time or penalties on the full form.
+func applyScoreSubmission(db *sql.DB, tournament tournament, s scoreSubmission) error {
+	if _, err := findScoreableMatch(db, tournament, s.Ref); err != nil {
+		return err
+	}
+	poolIndex, matchID, key, err := parseMatchRef(s.Ref)
+	if err != nil {
+		return errUnknownMatch
+	}
+	homeTeamGoals, visitorTeamGoals, sets, err := tournamentScoringModel(tournament).ParseScore(
+		strconv.Itoa(s.HomeTeamGoals), strconv.Itoa(s.VisitorTeamGoals), s.Sets)
+	if err != nil || (key != "" && homeTeamGoals == visitorTeamGoals) {
+		return errInvalidScore
+	}
+	before := loadWebhookState(db, tournament.ID)
+	if key == "" {
+		savePoolMatchScore(db, tournament.ID, poolIndex, matchID, resultPlayed, homeTeamGoals, visitorTeamGoals)
+		saveMatchSets(db, tournament.ID, s.Ref, sets)
+		completePool(db, tournament.ID, poolIndex)
+	} else {
+		recordRankingMatchResult(db, tournament.ID, key, resultPlayed, rankingMatchScore{HomeTeamGoals: homeTeamGoals, VisitorTeamGoals: visitorTeamGoals})
+		saveMatchSets(db, tournament.ID, s.Ref, sets)
+		applyWithdrawals(db, tournament.ID)
+	}
+	fireWebhooks(db, tournament.ID, s.Ref, before)
+	return nil
+}
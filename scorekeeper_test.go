@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestScorekeeperMatches(t *testing.T) {
+	matches := []displayMatch{
+		{Ref: "pool-1-1", PitchID: 1, Start: parseTime("09:00"), Played: true},
+		{Ref: "pool-1-2", PitchID: 1, Start: parseTime("09:40")},
+		{Ref: "pool-2-1", PitchID: 2, Start: parseTime("09:00")},
+		{Ref: "ranking-F", PitchID: 1, Start: parseTime("09:20")},
+		{Ref: "pool-1-3", PitchID: 1, Start: parseTime("08:00"), Played: true},
+		{Ref: "pool-1-4", PitchID: 1, Start: parseTime("08:20"), Played: true},
+		{Ref: "pool-1-5", PitchID: 1, Start: parseTime("08:40"), Played: true},
+	}
+	next, played := scorekeeperMatches(matches, 1)
+	if len(next) != 2 || next[0].Ref != "ranking-F" || next[1].Ref != "pool-1-2" {
+		t.Errorf("Expected the matches of the pitch still to be played in order, got %v.", next)
+	}
+	if len(played) != scorekeeperPlayedMatches || played[0].Ref != "pool-1-1" || played[2].Ref != "pool-1-4" {
+		t.Errorf("Expected the latest played matches first, got %v.", played)
+	}
+}
+
+func TestScoreSubmissionValid(t *testing.T) {
+	if !(scoreSubmission{SubmissionID: "3f2a", Ref: "pool-1-2"}).Valid() {
+		t.Errorf("Expected the submission to be valid.")
+	}
+	if (scoreSubmission{Ref: "pool-1-2"}).Valid() {
+		t.Errorf("Expected a submission without ID to be invalid.")
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	scoringGoals  = "goals"
+	scoringSets   = "sets"
+	scoringPoints = "points"
+)
+
+var errInvalidScore = errors.New("invalid score")
+
+type setScore struct {
+	HomePoints    int
+	VisitorPoints int
+}
+
+// scoringModel is the way matches of a tournament are scored. The scores of both teams are stored in the
+// goals columns whatever the model: goals, sets won or points.
+type scoringModel interface {
+	Name() string
+	Label() string
+	// ScoredHeader, ConcededHeader and BalanceHeader are the message IDs of the ranking columns.
+	ScoredHeader() string
+	ConcededHeader() string
+	BalanceHeader() string
+	// SetsEntry tells whether the score is entered set by set.
+	SetsEntry() bool
+	// DrawAllowed tells whether a match can end without a winner.
+	DrawAllowed() bool
+	// ParseScore reads the entered score, either both scores or the points of every set.
+	ParseScore(homeScore string, visitorScore string, sets string) (int, int, []setScore, error)
+}
+
+var scoringModels = []scoringModel{goalsScoring{}, setsScoring{}, pointsScoring{}}
+
+func findScoringModel(name string) (scoringModel, bool) {
+	for _, model := range scoringModels {
+		if model.Name() == name {
+			return model, true
+		}
+	}
+	return nil, false
+}
+
+func tournamentScoringModel(t tournament) scoringModel {
+	model, ok := findScoringModel(t.ScoringModel)
+	if !ok {
+		return goalsScoring{}
+	}
+	return model
+}
+
+type goalsScoring struct{}
+
+func (goalsScoring) Name() string           { return scoringGoals }
+func (goalsScoring) Label() string          { return "Buts" }
+func (goalsScoring) ScoredHeader() string   { return "goals_for_short" }
+func (goalsScoring) ConcededHeader() string { return "goals_against_short" }
+func (goalsScoring) BalanceHeader() string  { return "goal_balance_short" }
+func (goalsScoring) SetsEntry() bool        { return false }
+func (goalsScoring) DrawAllowed() bool      { return true }
+func (goalsScoring) ParseScore(homeScore string, visitorScore string, sets string) (int, int, []setScore, error) {
+	return parseScores(homeScore, visitorScore)
+}
+
+type pointsScoring struct{}
+
+func (pointsScoring) Name() string           { return scoringPoints }
+func (pointsScoring) Label() string          { return "Points" }
+func (pointsScoring) ScoredHeader() string   { return "points_for_short" }
+func (pointsScoring) ConcededHeader() string { return "points_against_short" }
+func (pointsScoring) BalanceHeader() string  { return "point_balance_short" }
+func (pointsScoring) SetsEntry() bool        { return false }
+func (pointsScoring) DrawAllowed() bool      { return true }
+func (pointsScoring) ParseScore(homeScore string, visitorScore string, sets string) (int, int, []setScore, error) {
+	return parseScores(homeScore, visitorScore)
+}
+
+type setsScoring struct{}
+
+func (setsScoring) Name() string           { return scoringSets }
+func (setsScoring) Label() string          { return "Sets" }
+func (setsScoring) ScoredHeader() string   { return "sets_won_short" }
+func (setsScoring) ConcededHeader() string { return "sets_lost_short" }
+func (setsScoring) BalanceHeader() string  { return "set_ratio" }
+func (setsScoring) SetsEntry() bool        { return true }
+func (setsScoring) DrawAllowed() bool      { return false }
+
+// ParseScore reads sets written as "25-20, 18-25, 15-10" and counts the sets won by each team.
+func (setsScoring) ParseScore(homeScore string, visitorScore string, sets string) (int, int, []setScore, error) {
+	parsed, err := parseSets(sets)
+	if err != nil || len(parsed) == 0 {
+		return 0, 0, nil, errInvalidScore
+	}
+	homeSets, visitorSets := 0, 0
+	for _, set := range parsed {
+		if set.HomePoints > set.VisitorPoints {
+			homeSets++
+		} else {
+			visitorSets++
+		}
+	}
+	if homeSets == visitorSets {
+		return 0, 0, nil, errInvalidScore
+	}
+	return homeSets, visitorSets, parsed, nil
+}
+
+func parseScores(homeScore string, visitorScore string) (int, int, []setScore, error) {
+	home, err1 := strconv.Atoi(strings.TrimSpace(homeScore))
+	visitor, err2 := strconv.Atoi(strings.TrimSpace(visitorScore))
+	if err1 != nil || err2 != nil || home < 0 || visitor < 0 {
+		return 0, 0, nil, errInvalidScore
+	}
+	return home, visitor, nil, nil
+}
+
+func parseSets(sets string) ([]setScore, error) {
+	slice := make([]setScore, 0)
+	for _, set := range strings.Split(sets, ",") {
+		set = strings.TrimSpace(set)
+		if set == "" {
+			continue
+		}
+		var score setScore
+		if _, err := fmt.Sscanf(set, "%d-%d", &score.HomePoints, &score.VisitorPoints); err != nil {
+			return nil, errInvalidScore
+		}
+		if score.HomePoints < 0 || score.VisitorPoints < 0 || score.HomePoints == score.VisitorPoints {
+			return nil, errInvalidScore
+		}
+		slice = append(slice, score)
+	}
+	return slice, nil
+}
+
+func formatSets(sets []setScore) string {
+	parts := make([]string, 0)
+	for _, set := range sets {
+		parts = append(parts, fmt.Sprintf("%d-%d", set.HomePoints, set.VisitorPoints))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ratio divides what a team scored by what it conceded, a team which conceded nothing having an infinite ratio.
+func ratio(scored int, conceded int) float64 {
+	if conceded == 0 {
+		if scored == 0 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	return float64(scored) / float64(conceded)
+}
+
+func formatRatio(value float64) string {
+	if math.IsInf(value, 1) {
+		return "∞"
+	}
+	return fmt.Sprintf("%.3f", value)
+}
+
+// rankPool ranks the teams of a pool according to the scoring model of the tournament. Sets tournaments
+// break ties on points with the set ratio, then with the ratio of the points played in the sets, then
+// with the fair-play points when the tournament uses them.
+func rankPool(db *sql.DB, tournamentID string, poolIndex int) []teamRanking {
+	rankings := selectTournamentPoolRanking(db, tournamentID, poolIndex)
+	tournament := selectTournament(db, tournamentID)
+	if tournamentScoringModel(tournament).Name() != scoringSets {
+		return rankings
+	}
+	pointsWon, pointsLost := selectPoolSetPoints(db, tournamentID, poolIndex)
+	for i := range rankings {
+		rankings[i].SetRatio = ratio(rankings[i].TeamGoals, rankings[i].OpponentGoals)
+		rankings[i].PointRatio = ratio(pointsWon[rankings[i].ID], pointsLost[rankings[i].ID])
+	}
+	sort.SliceStable(rankings, func(i, j int) bool {
+		a, b := rankings[i], rankings[j]
+		if a.Points != b.Points {
+			return a.Points > b.Points
+		}
+		if a.SetRatio != b.SetRatio {
+			return a.SetRatio > b.SetRatio
+		}
+		if a.PointRatio != b.PointRatio {
+			return a.PointRatio > b.PointRatio
+		}
+		if tournament.FairPlayTieBreak && a.FairPlayPoints != b.FairPlayPoints {
+			return a.FairPlayPoints < b.FairPlayPoints
+		}
+		return a.Name < b.Name
+	})
+	for i := range rankings {
+		rankings[i].Rank = i + 1
+	}
+	return rankings
+}
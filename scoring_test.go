@@ -0,0 +1,52 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSetsScoringParseScore(t *testing.T) {
+	home, visitor, sets, err := setsScoring{}.ParseScore("", "", "25-20, 18-25 ,15-10")
+	if err != nil {
+		t.Fatalf("Expected a valid score, got %v.", err)
+	}
+	if home != 2 || visitor != 1 {
+		t.Errorf("Expected 2 sets to 1, got %d to %d.", home, visitor)
+	}
+	if len(sets) != 3 || sets[1] != (setScore{HomePoints: 18, VisitorPoints: 25}) {
+		t.Errorf("Expected the points of the 3 sets, got %v.", sets)
+	}
+	if formatSets(sets) != "25-20, 18-25, 15-10" {
+		t.Errorf("Expected sets to be formatted back, got %s.", formatSets(sets))
+	}
+}
+
+func TestSetsScoringRejectsInvalidScores(t *testing.T) {
+	for _, sets := range []string{"", "25-20, 20-25", "25-25", "25:20", "25-20, abc"} {
+		if _, _, _, err := (setsScoring{}).ParseScore("", "", sets); err == nil {
+			t.Errorf("Expected %q to be rejected.", sets)
+		}
+	}
+}
+
+func TestPointsScoringParseScore(t *testing.T) {
+	home, visitor, _, err := pointsScoring{}.ParseScore("78", " 64", "")
+	if err != nil || home != 78 || visitor != 64 {
+		t.Errorf("Expected 78 to 64, got %d to %d (%v).", home, visitor, err)
+	}
+	if _, _, _, err := (pointsScoring{}).ParseScore("-1", "2", ""); err == nil {
+		t.Errorf("Expected a negative score to be rejected.")
+	}
+}
+
+func TestRatio(t *testing.T) {
+	if ratio(3, 2) != 1.5 {
+		t.Errorf("Expected a ratio of 1.5, got %v.", ratio(3, 2))
+	}
+	if !math.IsInf(ratio(3, 0), 1) || formatRatio(ratio(3, 0)) != "∞" {
+		t.Errorf("Expected an infinite ratio when nothing was conceded.")
+	}
+	if ratio(0, 0) != 0 {
+		t.Errorf("Expected a zero ratio when nothing was played.")
+	}
+}
@@ -8,10 +8,12 @@ import (
 	"github.com/foolin/goview/supports/echoview"
 	"github.com/foolin/goview/supports/gorice"
 	"github.com/thoas/go-funk"
-	"html/template"
 	"net/http"
+	"net/url"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo"
@@ -22,6 +24,11 @@ import (
 func main() {
 	db := initDB()
 
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(db, os.Args[1:]))
+	}
+	go runWebhookWorker(db, &http.Client{Timeout: 10 * time.Second}, 5*time.Second)
+
 	e := echo.New()
 	e.Debug = true
 
@@ -37,7 +44,7 @@ func main() {
 				Extension:    ".html",
 				Master:       "layouts/master",
 				Partials:     []string{"partials/fragments"},
-				Funcs:        make(template.FuncMap),
+				Funcs:        templateFuncs,
 				DisableCache: false,
 				Delims:       goview.Delims{Left: "{{", Right: "}}"},
 			},
@@ -58,6 +65,53 @@ func main() {
 	e.GET("/", index(db))
 	e.GET("/admin", admin(db))
 	e.GET("/admin/tournaments/:id", adminTournament(db))
+	e.POST("/admin/tournaments/:id/duplicate", duplicateTournament(db))
+	e.POST("/admin/tournaments/:id/status", postTournamentStatus(db))
+	e.POST("/admin/tournaments/:id/publish", publishTournament(db))
+	e.POST("/admin/tournaments/:id/schedule-settings", postScheduleSettings(db))
+	e.POST("/admin/tournaments/:id/locale", postTournamentLocale(db))
+	e.POST("/admin/tournaments/:id/forfeit-settings", postForfeitSettings(db))
+	e.POST("/admin/tournaments/:id/teams/:teamId/withdraw", postWithdrawTeam(db))
+	e.GET("/admin/tournaments/:id/teams/:teamId/players", adminTeamPlayers(db))
+	e.POST("/admin/tournaments/:id/teams/:teamId/players", postPlayer(db))
+	e.DELETE("/admin/tournaments/:id/teams/:teamId/players/:playerId", removePlayer(db))
+	e.GET("/admin/tournaments/:id/matches/:ref/goals", adminMatchGoals(db))
+	e.POST("/admin/tournaments/:id/matches/:ref/goals", postMatchGoals(db))
+	e.POST("/admin/tournaments/:id/fair-play-settings", postFairPlaySettings(db))
+	e.POST("/admin/tournaments/:id/normalisation-settings", postNormalisationSettings(db))
+	e.POST("/admin/tournaments/:id/final-ranking-settings", postFinalRankingSettings(db))
+	e.GET("/admin/tournaments/:id/referees", adminReferees(db))
+	e.POST("/admin/tournaments/:id/referees", postReferee(db))
+	e.DELETE("/admin/tournaments/:id/referees/:refereeId", removeReferee(db))
+	e.POST("/admin/tournaments/:id/referees/assign", postAssignReferee(db))
+	e.POST("/admin/tournaments/:id/referees/auto", postAutoAssignReferees(db))
+	e.GET("/admin/tournaments/:id/referees/schedule/:official", adminRefereeSchedule(db))
+	e.GET("/admin/tournaments/:id/matches/:ref/cards", adminMatchCards(db))
+	e.POST("/admin/tournaments/:id/matches/:ref/cards", postCard(db))
+	e.DELETE("/admin/tournaments/:id/matches/:ref/cards/:cardId", removeCard(db))
+	e.GET("/admin/tournaments/:id/schedule", adminSchedule(db))
+	e.GET("/admin/tournaments/:id/printouts", adminPrintouts(db))
+	e.GET("/admin/tournaments/:id/printouts/:document", getPrintout(db))
+	e.GET("/admin/tournaments/:id/exports/:table", getExport(db))
+	e.GET("/admin/tournaments/:id/scorekeeper", adminScorekeeper(db))
+	e.POST("/admin/tournaments/:id/scorekeeper/scores", postScoreSubmission(db))
+	e.POST("/admin/tournaments/:id/scorekeeper/live", postLiveAction(db))
+	e.GET("/admin/tournaments/:id/webhooks", adminWebhooks(db))
+	e.POST("/admin/tournaments/:id/webhooks", postWebhook(db))
+	e.DELETE("/admin/tournaments/:id/webhooks/:webhookId", removeWebhook(db))
+	e.POST("/admin/tournaments/:id/webhooks/deliveries/:deliveryId/retry", postRetryWebhookDelivery(db))
+	e.POST("/admin/tournaments/:id/schedule/move", postMoveMatch(db))
+	e.POST("/admin/tournaments/:id/schedule/shift", postShiftSchedule(db))
+	e.POST("/admin/tournaments/:id/restore", postRestoreTournament(db))
+	e.DELETE("/admin/tournaments/:id", purgeTournament(db))
+	e.GET("/admin/trash", adminTrash(db))
+	e.GET("/admin/displays", adminDisplays(db))
+	e.POST("/admin/displays", postDisplay(db))
+	e.GET("/admin/displays/:id", adminDisplay(db))
+	e.POST("/admin/displays/:id", postDisplaySettings(db))
+	e.DELETE("/admin/displays/:id", removeDisplay(db))
+	e.POST("/admin/displays/:id/slides", postDisplaySlide(db))
+	e.DELETE("/admin/displays/:id/slides/:slideId", removeDisplaySlide(db))
 	e.POST("/admin/tournaments/:id/teams", postTeamNames(db))
 	e.GET("/admin/tournaments/:id/pools-matches", poolsMatchesScores(db))
 	e.GET("/admin/tournaments/:id/ranking-matches", rankingMatchesScores(db))
@@ -66,8 +120,19 @@ func main() {
 	e.GET("/tournaments/:id/pools/:poolIndex/matches", getPoolMatches(db))
 	e.GET("/tournaments/:id/pools/ranking", getAllTournamentPoolsRanking(db))
 	e.GET("/tournaments/:id/pools/:poolIndex/ranking", getPoolRanking(db))
+	e.GET("/tournaments/:id/pools/:poolIndex/scenarios", getPoolScenarios(db))
 	e.GET("/tournaments/:id/ranking-matches", getTournamentRankingMatches(db))
+	e.GET("/tournaments/:id/bracket", getBracket(db))
 	e.GET("/tournaments/:id/final-ranking", getFinalRanking(db))
+	e.GET("/tournaments/:id/teams/:teamId", getTeam(db))
+	e.GET("/tournaments/:id/calendar.ics", getTournamentCalendar(db))
+	e.GET("/tournaments/:id/pools/:poolIndex/calendar.ics", getPoolCalendar(db))
+	e.GET("/tournaments/:id/teams/:teamId/calendar.ics", getTeamCalendar(db))
+	e.GET("/tournaments/:id/pitches/:pitchId/calendar.ics", getPitchCalendar(db))
+	e.GET("/tournaments/:id/scorers", getTournamentScorers(db))
+	e.GET("/tournaments/:id/fair-play", getFairPlay(db))
+	e.GET("/scorers", getTopScorers(db))
+	e.GET("/displays/:id", getDisplay(db))
 	e.POST("/tournaments", createTournament(db))
 	e.DELETE("/tournaments/:id", removeTournament(db))
 	e.POST("/tournaments/:tournamentId/pools/:poolIndex/matches/:matchId/score", postPoolMatchScore(db))
@@ -83,11 +148,21 @@ func main() {
 func index(db *sql.DB) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		tournaments := selectTournaments(db)
+		tournaments = funk.Filter(tournaments, func(tournament tournament) bool {
+			return tournament.Listed()
+		}).([]tournament)
 		tournaments = funk.Map(tournaments, func(tournament tournament) tournament {
 			tournament.Pools = selectTournamentPools(db, tournament.ID)
+			tournament.Teams = selectTournamentTeams(db, tournament.ID)
 			return tournament
 		}).([]tournament)
-		return c.Render(http.StatusOK, "index", echo.Map{"title": "Tounois", "tournaments": tournaments})
+		locale := requestLocale(c, defaultLocale)
+		return c.Render(http.StatusOK, "index", echo.Map{
+			"title":       translate(locale, "tournaments"),
+			"locale":      locale,
+			"locales":     localeLinks(c),
+			"tournaments": tournaments,
+		})
 	}
 }
 func admin(db *sql.DB) echo.HandlerFunc {
@@ -97,7 +172,23 @@ func admin(db *sql.DB) echo.HandlerFunc {
 			tournament.Pools = selectTournamentPools(db, tournament.ID)
 			return tournament
 		}).([]tournament)
-		return c.Render(http.StatusOK, "admin/index", echo.Map{"title": "Admin", "tournaments": tournaments})
+		return c.Render(http.StatusOK, "admin/index", echo.Map{
+			"title":            "Admin",
+			"tournaments":      tournaments,
+			"statuses":         tournamentStatuses,
+			"locales":          locales,
+			"scorings":         scoringModels,
+			"duplicateID":      c.FormValue("error") == "duplicate_id",
+			"invalidDuplicate": c.FormValue("error") == "invalid_duplicate",
+		})
+	}
+}
+func adminTrash(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return c.Render(http.StatusOK, "admin/trash", echo.Map{
+			"title":       "Corbeille",
+			"tournaments": selectDeletedTournaments(db),
+		})
 	}
 }
 func adminTournament(db *sql.DB) echo.HandlerFunc {
@@ -109,10 +200,48 @@ func adminTournament(db *sql.DB) echo.HandlerFunc {
 		return c.Render(
 			http.StatusOK,
 			"admin/tournament",
-			echo.Map{"title": "Scores", "tournament": tournament, "teams": teams, "matches": matches},
+			echo.Map{
+				"title":         "Scores",
+				"tournament":    tournament,
+				"teams":         teams,
+				"matches":       matches,
+				"draft":         tournament.Status == statusDraft,
+				"checklist":     publishChecklist(db, tournamentID),
+				"publishFailed": c.FormValue("error") == "checklist",
+				"scheduleIssues": validateSchedule(
+					loadScheduledMatches(db, tournamentID),
+					tournamentScheduleSettings(tournament),
+				),
+				"invalidWindows":     c.FormValue("error") == "invalid_windows",
+				"invalidDate":        c.FormValue("error") == "invalid_date",
+				"locales":            locales,
+				"normalisations":     normalisations,
+				"eliminatedRankings": eliminatedRankings,
+				"pools":              selectTournamentPools(db, tournamentID),
+				"pitches":            selectTournamentPitches(db, tournamentID),
+			},
 		)
 	}
 }
+func adminSchedule(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return renderSchedule(c, db, c.Param("id"), http.StatusOK, nil)
+	}
+}
+func renderSchedule(c echo.Context, db *sql.DB, tournamentID string, status int, conflicts []scheduleIssue) error {
+	tournament := selectTournament(db, tournamentID)
+	pitches := selectTournamentPitches(db, tournamentID)
+	matches := loadScheduledMatches(db, tournamentID)
+	return c.Render(status, "admin/schedule", echo.Map{
+		"title":      "Planning",
+		"tournament": tournament,
+		"pitches":    pitches,
+		"matches":    matches,
+		"slots":      scheduleGrid(pitches, matches),
+		"issues":     validateSchedule(matches, tournamentScheduleSettings(tournament)),
+		"conflicts":  conflicts,
+	})
+}
 func poolsMatchesScores(db *sql.DB) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		tournamentID := c.Param("id")
@@ -121,9 +250,12 @@ func poolsMatchesScores(db *sql.DB) echo.HandlerFunc {
 		to := timeParam(c,"to")
 		pools := loadAllPoolsMatches(db, tournamentID, from, to)
 		return c.Render(http.StatusOK, "admin/pools-matches", echo.Map{
-			"title":      "Scores",
-			"tournament": tournament,
-			"pools":      pools,
+			"title":          "Scores",
+			"tournament":     tournament,
+			"pools":          pools,
+			"resultStatuses": resultStatuses,
+			"scoring":        tournamentScoringModel(tournament),
+			"invalidScore":   c.FormValue("error") == "invalid_score",
 		})
 	}
 }
@@ -131,12 +263,14 @@ func rankingMatchesScores(db *sql.DB) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		tournamentID := c.Param("id")
 		tournament := selectTournament(db, tournamentID)
-		rankingMatches := tournamentRankingMatches(db, tournamentID, NullTime{time.Time{}, false}, NullTime{time.Time{}, false})
+		rankingMatches := tournamentRankingMatches(db, tournamentID, defaultLocale, NullTime{time.Time{}, false}, NullTime{time.Time{}, false})
 		return c.Render(http.StatusOK, "admin/ranking-matches", echo.Map{
 			"title":          "Scores",
 			"tournament":     tournament,
 			"rankingMatches": rankingMatches,
 			"invalidScore":   c.FormValue("error") == "invalid_score",
+			"resultStatuses": rankingMatchResultStatuses,
+			"scoring":        tournamentScoringModel(tournament),
 		})
 	}
 }
@@ -144,13 +278,19 @@ func rankingMatchesScores(db *sql.DB) echo.HandlerFunc {
 func getAllTournamentMatches(db *sql.DB) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		tournamentID := c.Param("id")
-		tournament := selectTournament(db, tournamentID)
+		tournament, err := loadPublicTournament(db, tournamentID)
+		if err != nil {
+			return err
+		}
 		from := timeParam(c,"from")
 		to := timeParam(c,"to")
 		pools := loadAllPoolsMatches(db, tournamentID, from, to)
-		rankingMatches := tournamentRankingMatches(db, tournamentID, from, to)
+		locale := requestLocale(c, tournament.Locale)
+		rankingMatches := tournamentRankingMatches(db, tournamentID, locale, from, to)
 		return c.Render(http.StatusOK, "all-matches", echo.Map{
-			"title":                "Rencontres",
+			"title":                translate(locale, "matches"),
+			"locale":               locale,
+			"locales":              localeLinks(c),
 			"tournament":           tournament,
 			"pools":                pools,
 			"rankingMatches":       rankingMatches,
@@ -161,12 +301,18 @@ func getAllTournamentMatches(db *sql.DB) echo.HandlerFunc {
 func getAllTournamentPoolsMatches(db *sql.DB) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		tournamentID := c.Param("id")
-		tournament := selectTournament(db, tournamentID)
+		tournament, err := loadPublicTournament(db, tournamentID)
+		if err != nil {
+			return err
+		}
 		from := timeParam(c,"from")
 		to := timeParam(c,"to")
 		pools := loadAllPoolsMatches(db, tournamentID, from, to)
+		locale := requestLocale(c, tournament.Locale)
 		return c.Render(http.StatusOK, "pools-matches", echo.Map{
-			"title":      "Rencontres",
+			"title":      translate(locale, "matches"),
+			"locale":     locale,
+			"locales":    localeLinks(c),
 			"tournament": tournament,
 			"pools":      pools,
 		})
@@ -175,18 +321,113 @@ func getAllTournamentPoolsMatches(db *sql.DB) echo.HandlerFunc {
 func getTournamentRankingMatches(db *sql.DB) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		tournamentID := c.Param("id")
-		tournament := selectTournament(db, tournamentID)
+		tournament, err := loadPublicTournament(db, tournamentID)
+		if err != nil {
+			return err
+		}
 		from := timeParam(c,"from")
 		to := timeParam(c,"to")
-		matches := tournamentRankingMatches(db, tournamentID, from, to)
+		locale := requestLocale(c, tournament.Locale)
+		matches := tournamentRankingMatches(db, tournamentID, locale, from, to)
 		return c.Render(http.StatusOK, "ranking-matches", echo.Map{
-			"title":                "Rencontres",
+			"title":                translate(locale, "matches"),
+			"locale":               locale,
+			"locales":              localeLinks(c),
 			"tournament":           tournament,
 			"rankingMatches":       matches,
 			"uniqRankingPitchName": uniqRankingPitchName(matches),
 		})
 	}
 }
+func getBracket(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		tournament, err := loadPublicTournament(db, tournamentID)
+		if err != nil {
+			return err
+		}
+		locale := requestLocale(c, tournament.Locale)
+		matches := tournamentRankingMatches(db, tournamentID, locale, NullTime{}, NullTime{})
+		return c.Render(http.StatusOK, "bracket", echo.Map{
+			"title":      translate(locale, "bracket"),
+			"locale":     locale,
+			"locales":    localeLinks(c),
+			"tournament": tournament,
+			"brackets":   rankingBrackets(matches),
+			"wide":       true,
+		})
+	}
+}
+func getTournamentCalendar(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return renderCalendar(c, db, func(t tournament, matches []displayMatch) (string, []displayMatch, int, error) {
+			return t.Name, matches, 0, nil
+		})
+	}
+}
+func getPoolCalendar(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		poolIndex, _ := strconv.Atoi(c.Param("poolIndex"))
+		return renderCalendar(c, db, func(t tournament, matches []displayMatch) (string, []displayMatch, int, error) {
+			found := funk.Find(selectTournamentPools(db, t.ID), func(p pool) bool { return p.Index == poolIndex })
+			if found == nil {
+				return "", nil, 0, echo.ErrNotFound
+			}
+			matches = funk.Filter(matches, func(m displayMatch) bool { return m.PoolIndex == poolIndex }).([]displayMatch)
+			return t.Name + " - " + translate(t.Locale, "pool", found.(pool).Name), matches, 0, nil
+		})
+	}
+}
+func getTeamCalendar(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		teamID, _ := strconv.Atoi(c.Param("teamId"))
+		return renderCalendar(c, db, func(t tournament, matches []displayMatch) (string, []displayMatch, int, error) {
+			found := funk.Find(selectTournamentTeams(db, t.ID), func(team team) bool { return team.ID == teamID })
+			if found == nil {
+				return "", nil, 0, echo.ErrNotFound
+			}
+			matches = funk.Filter(matches, func(m displayMatch) bool { return m.HomeTeamID == teamID || m.VisitorTeamID == teamID }).([]displayMatch)
+			return t.Name + " - " + found.(team).Name, matches, teamID, nil
+		})
+	}
+}
+func getPitchCalendar(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		pitchID, _ := strconv.Atoi(c.Param("pitchId"))
+		return renderCalendar(c, db, func(t tournament, matches []displayMatch) (string, []displayMatch, int, error) {
+			found := funk.Find(selectTournamentPitches(db, t.ID), func(p pitch) bool { return p.ID == pitchID })
+			if found == nil {
+				return "", nil, 0, echo.ErrNotFound
+			}
+			matches = funk.Filter(matches, func(m displayMatch) bool { return m.PitchID == pitchID }).([]displayMatch)
+			return t.Name + " - " + translate(t.Locale, "pitch", found.(pitch).Name), matches, 0, nil
+		})
+	}
+}
+
+// renderCalendar writes the iCalendar feed of the matches selected among those of the public tournament.
+// A tournament without date has no feed.
+func renderCalendar(c echo.Context, db *sql.DB, selectMatches func(tournament, []displayMatch) (string, []displayMatch, int, error)) error {
+	tournament, err := loadPublicTournament(db, c.Param("id"))
+	if err != nil {
+		return err
+	}
+	if tournament.Date == "" {
+		return echo.ErrNotFound
+	}
+	locale := requestLocale(c, tournament.Locale)
+	name, matches, teamID, err := selectMatches(tournament, loadDisplayMatches(db, tournament, locale))
+	if err != nil {
+		return err
+	}
+	events, err := calendarEvents(tournament, matches, teamID, locale)
+	if err != nil {
+		return err
+	}
+	c.Response().Header().Set(echo.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Response().WriteHeader(http.StatusOK)
+	return writeCalendar(c.Response(), name, events, time.Now())
+}
 
 func uniqRankingPitchName(matches []rankingMatch) sql.NullString {
 	pitchNames := funk.Map(matches, func(match rankingMatch) string { return match.PitchName }).([]string)
@@ -201,21 +442,39 @@ func uniqRankingPitchName(matches []rankingMatch) sql.NullString {
 func getPoolMatches(db *sql.DB) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		tournamentID := c.Param("id")
-		tournament := selectTournament(db, tournamentID)
+		tournament, err := loadPublicTournament(db, tournamentID)
+		if err != nil {
+			return err
+		}
 		poolIndex, _ := strconv.Atoi(c.Param("poolIndex"))
 		from := timeParam(c,"from")
 		to := timeParam(c,"to")
 		_pool := selectTournamentPool(db, tournamentID, poolIndex)
 		poolMatches := loadPoolMatches(db, _pool, from, to)
 
+		locale := requestLocale(c, tournament.Locale)
 		return c.Render(http.StatusOK, "pool-matches", echo.Map{
-			"title":      "Rencontres poule" + _pool.Name,
+			"title":      translate(locale, "pool_matches", _pool.Name),
+			"locale":     locale,
+			"locales":    localeLinks(c),
 			"tournament": tournament,
 			"pool":       poolMatches,
 		})
 	}
 }
 
+// loadPublicTournament returns the tournament if it can be browsed on public pages.
+func loadPublicTournament(db *sql.DB, tournamentID string) (tournament, error) {
+	if !tournamentExists(db, tournamentID) {
+		return tournament{}, echo.ErrNotFound
+	}
+	tournament := selectTournament(db, tournamentID)
+	if tournament.DeletedAt.Valid || tournament.Status == statusDraft {
+		return tournament, echo.ErrNotFound
+	}
+	return tournament, nil
+}
+
 func timeParam(c echo.Context, name string) NullTime {
 	fromStr := c.FormValue(name)
 	var from NullTime
@@ -227,51 +486,48 @@ func timeParam(c echo.Context, name string) NullTime {
 	return from
 }
 
-func tournamentRankingMatches(db *sql.DB, tournamentID string, from NullTime, to NullTime) []rankingMatch {
+func tournamentRankingMatches(db *sql.DB, tournamentID string, locale string, from NullTime, to NullTime) []rankingMatch {
 	matches := selectTournamentRankingMatches(db, tournamentID, from, to)
 	pools := selectTournamentPools(db, tournamentID)
+	sets := selectMatchSets(db, tournamentID)
+	live := loadLiveMatches(db, tournamentID)
+	provisional := provisionalTeams(db, tournamentID, pools)
+	provisionalBest := provisionalBestTeams(db, tournamentID, pools, matches)
 	matches = funk.Map(matches, func(match rankingMatch) rankingMatch {
+		match.Sets = sets[rankingMatchRef(match.Key)]
+		match.Live = live[rankingMatchRef(match.Key)]
 		match.ValidTeams = match.HomeTeamName.Valid && match.VisitorTeamName.Valid
-		if match.HomeTeamGoals.Valid && match.VisitorTeamGoals.Valid && match.HomeTeamGoals.Int64 == match.VisitorTeamGoals.Int64 {
-			if match.WinnerTeamID.Int64 == match.HomeTeamID.Int64 {
-				match.PenaltyShootOutWinner = "home"
-			} else {
-				match.PenaltyShootOutWinner = "visitor"
-			}
-		} else {
-			match.PenaltyShootOutWinner = "none"
-		}
 		if !match.HomeTeamName.Valid {
-			match.HomeTeamName = rankingMatchTeamName(pools, match.HomeTeamPoolIndex, match.HomeTeamPoolRank, match.HomeTeamSourceRankingMatch, match.HomeTeamSourceRankingMatchWinner)
+			match.HomeTeamName = rankingMatchTeamName(locale, pools, match.HomeTeamPoolIndex, match.HomeTeamPoolRank, match.HomeTeamSourceRankingMatch, match.HomeTeamSourceRankingMatchWinner, match.HomeTeamBestRank, match.HomeTeamBestPosition)
+			match.HomeTeamProvisional = provisional[int(match.HomeTeamPoolIndex.Int64)][int(match.HomeTeamPoolRank.Int64)]
+			if match.HomeTeamBestRank.Valid {
+				match.HomeTeamProvisional = provisionalBest[int(match.HomeTeamBestRank.Int64)][int(match.HomeTeamBestPosition.Int64)]
+			}
 		}
 		if !match.VisitorTeamName.Valid {
-			match.VisitorTeamName = rankingMatchTeamName(pools, match.VisitorTeamPoolIndex, match.VisitorTeamPoolRank, match.VisitorTeamSourceRankingMatch, match.VisitorTeamSourceRankingMatchWinner)
+			match.VisitorTeamName = rankingMatchTeamName(locale, pools, match.VisitorTeamPoolIndex, match.VisitorTeamPoolRank, match.VisitorTeamSourceRankingMatch, match.VisitorTeamSourceRankingMatchWinner, match.VisitorTeamBestRank, match.VisitorTeamBestPosition)
+			match.VisitorTeamProvisional = provisional[int(match.VisitorTeamPoolIndex.Int64)][int(match.VisitorTeamPoolRank.Int64)]
+			if match.VisitorTeamBestRank.Valid {
+				match.VisitorTeamProvisional = provisionalBest[int(match.VisitorTeamBestRank.Int64)][int(match.VisitorTeamBestPosition.Int64)]
+			}
 		}
 		return match
 	}).([]rankingMatch)
 	return matches
 }
-func rankingMatchTeamName(pools []pool, poolIndex sql.NullInt64, poolRank sql.NullInt64, rankingMatchKey sql.NullString, rankingMatchWinner sql.NullBool) sql.NullString {
+func rankingMatchTeamName(locale string, pools []pool, poolIndex sql.NullInt64, poolRank sql.NullInt64, rankingMatchKey sql.NullString, rankingMatchWinner sql.NullBool, bestRank sql.NullInt64, bestPosition sql.NullInt64) sql.NullString {
 	var name string
-	if poolIndex.Valid {
+	if bestRank.Valid {
+		name = translate(locale, "best_rank_team", bestPosition.Int64, ordinal(locale, int(bestRank.Int64)))
+	} else if poolIndex.Valid {
 		pool := funk.Find(pools, func(p pool) bool {
 			return p.Index == int(poolIndex.Int64)
 		}).(pool)
-		var prefix string
-		if poolRank.Int64 == 1 {
-			prefix = "1er"
-		} else {
-			prefix = fmt.Sprintf("%deme", poolRank.Int64)
-		}
-		name = fmt.Sprintf("%s poule %s", prefix, pool.Name)
+		name = translate(locale, "pool_rank_team", ordinal(locale, int(poolRank.Int64)), pool.Name)
+	} else if rankingMatchWinner.Bool {
+		name = translate(locale, "winner_of_match", rankingMatchKey.String)
 	} else {
-		var prefix string
-		if rankingMatchWinner.Bool {
-			prefix = "Gagnant"
-		} else {
-			prefix = "Perdant"
-		}
-		name = fmt.Sprintf("%s match %s", prefix, rankingMatchKey.String)
+		name = translate(locale, "loser_of_match", rankingMatchKey.String)
 	}
 	return sql.NullString{String: name, Valid: true}
 }
@@ -286,6 +542,12 @@ func loadAllPoolsMatches(db *sql.DB, tournamentID string, from NullTime, to Null
 
 func loadPoolMatches(db *sql.DB, pool pool, from NullTime, to NullTime) poolViewModel {
 	matches := selectTournamentPoolMatches(db, pool.TournamentID, pool.Index, from, to)
+	sets := selectMatchSets(db, pool.TournamentID)
+	live := loadLiveMatches(db, pool.TournamentID)
+	for i := range matches {
+		matches[i].Sets = sets[poolMatchRef(pool.Index, matches[i].ID)]
+		matches[i].Live = live[poolMatchRef(pool.Index, matches[i].ID)]
+	}
 	pitchNames := funk.Map(matches, func(match poolMatch) string { return match.PitchName }).([]string)
 	pitchNames = funk.UniqString(pitchNames)
 	uniqPitchName := sql.NullString{String: "", Valid: false}
@@ -303,39 +565,180 @@ func loadPoolMatches(db *sql.DB, pool pool, from NullTime, to NullTime) poolView
 func getAllTournamentPoolsRanking(db *sql.DB) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		tournamentID := c.Param("id")
-		tournament := selectTournament(db, tournamentID)
+		tournament, err := loadPublicTournament(db, tournamentID)
+		if err != nil {
+			return err
+		}
+		locale := requestLocale(c, tournament.Locale)
 		return c.Render(http.StatusOK, "pools-ranking", echo.Map{
-			"title":      "Classements",
+			"title":      translate(locale, "rankings"),
+			"locale":     locale,
+			"locales":    localeLinks(c),
 			"tournament": tournament,
+			"scoring":    tournamentScoringModel(tournament),
 			"pools":      loadAllTournamentPoolsRanking(db, tournamentID),
+			"bestRanked": loadBestRanked(db, tournament),
 		})
 	}
 }
 func getFinalRanking(db *sql.DB) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		tournamentID := c.Param("id")
-		tournament := selectTournament(db, tournamentID)
-		finalRanking := selectTournamentFinalRanking(db, tournamentID)
+		tournament, err := loadPublicTournament(db, tournamentID)
+		if err != nil {
+			return err
+		}
+		finalRanking := loadFinalRanking(db, tournamentID)
+		locale := requestLocale(c, tournament.Locale)
 		return c.Render(http.StatusOK, "final-ranking", echo.Map{
-			"title":      "Classements",
+			"title":      translate(locale, "final_ranking"),
+			"locale":     locale,
+			"locales":    localeLinks(c),
 			"tournament": tournament,
+			"scoring":    tournamentScoringModel(tournament),
 			"ranking":    finalRanking,
 		})
 	}
 }
+func getTeam(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		tournament, err := loadPublicTournament(db, tournamentID)
+		if err != nil {
+			return err
+		}
+		teamID, _ := strconv.Atoi(c.Param("teamId"))
+		found := funk.Find(selectTournamentTeams(db, tournamentID), func(team team) bool {
+			return team.ID == teamID
+		})
+		if found == nil {
+			return echo.ErrNotFound
+		}
+		team := found.(team)
+		pool := selectTournamentPool(db, tournamentID, team.PoolIndex)
+		ranking := loadPoolRanking(db, tournamentID, pool)
+		position := funk.Find(ranking.TeamRankings, func(teamRanking teamRanking) bool {
+			return teamRanking.ID == teamID
+		}).(teamRanking)
+		locale := requestLocale(c, tournament.Locale)
+		rankingMatches := tournamentRankingMatches(db, tournamentID, locale, NullTime{}, NullTime{})
+		return c.Render(http.StatusOK, "team", echo.Map{
+			"title":       team.Name,
+			"locale":      locale,
+			"locales":     localeLinks(c),
+			"tournament":  tournament,
+			"team":        team,
+			"pool":        pool,
+			"position":    position,
+			"ranking":     ranking,
+			"scoring":     tournamentScoringModel(tournament),
+			"matches":     teamMatches(locale, teamID, pool, loadPoolMatches(db, pool, NullTime{}, NullTime{}).Matches, rankingMatches),
+			"bracket":     teamBracketPath(teamID, rankingMatches),
+			"provisional": teamProvisionalMatches(teamID, rankingMatches),
+		})
+	}
+}
+func getTournamentScorers(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		tournament, err := loadPublicTournament(db, tournamentID)
+		if err != nil {
+			return err
+		}
+		locale := requestLocale(c, tournament.Locale)
+		return c.Render(http.StatusOK, "scorers", echo.Map{
+			"title":      translate(locale, "top_scorers"),
+			"locale":     locale,
+			"locales":    localeLinks(c),
+			"tournament": tournament,
+			"scorers":    selectTournamentTopScorers(db, tournamentID),
+		})
+	}
+}
+func getFairPlay(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		tournament, err := loadPublicTournament(db, tournamentID)
+		if err != nil {
+			return err
+		}
+		locale := requestLocale(c, tournament.Locale)
+		return c.Render(http.StatusOK, "fair-play", echo.Map{
+			"title":       translate(locale, "fair_play"),
+			"locale":      locale,
+			"locales":     localeLinks(c),
+			"tournament":  tournament,
+			"ranking":     selectFairPlayTable(db, tournamentID),
+			"suspensions": tournamentSuspensions(db, tournament),
+		})
+	}
+}
+func getTopScorers(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		locale := requestLocale(c, defaultLocale)
+		return c.Render(http.StatusOK, "scorers", echo.Map{
+			"title":   translate(locale, "all_top_scorers"),
+			"locale":  locale,
+			"locales": localeLinks(c),
+			"scorers": selectTopScorers(db),
+		})
+	}
+}
 func getPoolRanking(db *sql.DB) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		tournamentID := c.Param("id")
-		tournament := selectTournament(db, tournamentID)
+		tournament, err := loadPublicTournament(db, tournamentID)
+		if err != nil {
+			return err
+		}
 		poolIndex, _ := strconv.Atoi(c.Param("poolIndex"))
 		pool := selectTournamentPool(db, tournamentID, poolIndex)
+		locale := requestLocale(c, tournament.Locale)
 		return c.Render(http.StatusOK, "pool-ranking", echo.Map{
-			"title":      "Classement poule" + pool.Name,
+			"title":      translate(locale, "pool_ranking", pool.Name),
+			"locale":     locale,
+			"locales":    localeLinks(c),
 			"tournament": tournament,
+			"scoring":    tournamentScoringModel(tournament),
 			"ranking":    loadPoolRanking(db, tournamentID, pool),
 		})
 	}
 }
+func getPoolScenarios(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		tournament, err := loadPublicTournament(db, tournamentID)
+		if err != nil {
+			return err
+		}
+		poolIndex, _ := strconv.Atoi(c.Param("poolIndex"))
+		pool := selectTournamentPool(db, tournamentID, poolIndex)
+		locale := requestLocale(c, tournament.Locale)
+		remaining := funk.Filter(loadPoolMatches(db, pool, NullTime{}, NullTime{}).Matches, func(match poolMatch) bool {
+			return !match.Played()
+		}).([]poolMatch)
+		places, bestRanks := qualifyingPlaces(poolIndex, tournamentRankingMatches(db, tournamentID, locale, NullTime{}, NullTime{}))
+		margins := scenarioMargins(tournament, c.QueryParam("margins") == "1")
+		ranking := loadPoolRanking(db, tournamentID, pool)
+		scenarios, ok := poolScenarios(tournament, ranking.TeamRankings, remaining, places, bestRanks, margins)
+		return c.Render(http.StatusOK, "pool-scenarios", echo.Map{
+			"title":            translate(locale, "pool_scenarios", pool.Name),
+			"locale":           locale,
+			"locales":          localeLinks(c),
+			"tournament":       tournament,
+			"scoring":          tournamentScoringModel(tournament),
+			"pool":             pool,
+			"ranking":          ranking,
+			"remaining":        remaining,
+			"qualifyingPlaces": places,
+			"margins":          margins,
+			"maxMargin":        scenarioMaxMargin,
+			"marginsOptional":  scenarioMargins(tournament, false) != scenarioMargins(tournament, true),
+			"scenarios":        scenarios,
+			"tooMany":          !ok,
+		})
+	}
+}
 func loadAllTournamentPoolsRanking(db *sql.DB, tournamentID string) []rankingViewModel {
 	pools := selectTournamentPools(db, tournamentID)
 	rankingViews := make([]rankingViewModel, 0)
@@ -349,40 +752,490 @@ func loadPoolRanking(db *sql.DB, tournamentID string, pool pool) rankingViewMode
 	return rankingViewModel{
 		PoolIndex:    pool.Index,
 		PoolName:     pool.Name,
-		TeamRankings: selectTournamentPoolRanking(db, tournamentID, pool.Index),
+		TeamRankings: rankPool(db, tournamentID, pool.Index),
 	}
 }
 func removeTournament(db *sql.DB) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		tournamentID := c.Param("id")
+		trashTournament(db, tournamentID)
+		return c.Redirect(http.StatusSeeOther, "/admin")
+	}
+}
+func postRestoreTournament(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		if !tournamentExists(db, tournamentID) {
+			return echo.ErrNotFound
+		}
+		restoreTournament(db, tournamentID)
+		return c.Redirect(http.StatusSeeOther, "/admin/trash")
+	}
+}
+func purgeTournament(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		if !tournamentExists(db, tournamentID) {
+			return echo.ErrNotFound
+		}
+		if !selectTournament(db, tournamentID).DeletedAt.Valid {
+			return echo.NewHTTPError(http.StatusConflict, "Only tournaments in the trash can be deleted")
+		}
 		deleteTournament(db, tournamentID)
+		return c.Redirect(http.StatusSeeOther, "/admin/trash")
+	}
+}
+func publishTournament(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		if !checklistOK(publishChecklist(db, tournamentID)) {
+			return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID+"?error=checklist")
+		}
+		updateTournamentStatus(db, tournamentID, statusPublished)
+		return c.Redirect(http.StatusSeeOther, "/admin")
+	}
+}
+func postScheduleSettings(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		tournament := selectTournament(db, tournamentID)
+		previousDate := tournament.Date
+		tournament.GameDurationMinutes, _ = strconv.Atoi(c.FormValue("gameDurationMinutes"))
+		tournament.MinRestMinutes, _ = strconv.Atoi(c.FormValue("minRestMinutes"))
+		tournament.PlayingWindows = c.FormValue("playingWindows")
+		if _, err := parsePlayingWindows(tournament.PlayingWindows); err != nil {
+			return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID+"?error=invalid_windows")
+		}
+		tournament.Date = strings.TrimSpace(c.FormValue("date"))
+		tournament.TimeZone = strings.TrimSpace(c.FormValue("timeZone"))
+		date, err := time.Parse("2006-01-02", tournament.Date)
+		if err != nil {
+			return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID+"?error=invalid_date")
+		}
+		if _, err := time.LoadLocation(tournament.TimeZone); err != nil || tournament.TimeZone == "" {
+			return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID+"?error=invalid_date")
+		}
+		if previous, err := time.Parse("2006-01-02", previousDate); err == nil && !previous.Equal(date) {
+			shiftTournamentDays(db, tournamentID, int(date.Sub(previous).Hours()/24))
+		}
+		updateTournamentScheduleSettings(db, tournament)
+		return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID)
+	}
+}
+func postTournamentLocale(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		locale := c.FormValue("locale")
+		if supportedLocale(locale) {
+			updateTournamentLocale(db, tournamentID, locale)
+		}
+		return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID)
+	}
+}
+func postForfeitSettings(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		tournament := selectTournament(db, tournamentID)
+		tournament.ForfeitGoals, _ = strconv.Atoi(c.FormValue("forfeitGoals"))
+		tournament.ForfeitPenaltyPoints, _ = strconv.ParseFloat(c.FormValue("forfeitPenaltyPoints"), 64)
+		tournament.ForfeitGoalsCounted = c.FormValue("forfeitGoalsCounted") == "on"
+		updateTournamentForfeitSettings(db, tournament)
+		return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID)
+	}
+}
+func postFairPlaySettings(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		tournament := selectTournament(db, tournamentID)
+		tournament.YellowCardPoints, _ = strconv.ParseFloat(c.FormValue("yellowCardPoints"), 64)
+		tournament.RedCardPoints, _ = strconv.ParseFloat(c.FormValue("redCardPoints"), 64)
+		tournament.YellowCardsPerSuspension, _ = strconv.Atoi(c.FormValue("yellowCardsPerSuspension"))
+		tournament.FairPlayTieBreak = c.FormValue("fairPlayTieBreak") == "on"
+		updateTournamentFairPlaySettings(db, tournament)
+		return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID)
+	}
+}
+func postNormalisationSettings(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		normalisation := c.FormValue("normalisation")
+		if validNormalisation(normalisation) {
+			updateTournamentNormalisation(db, tournamentID, normalisation)
+		}
+		return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID)
+	}
+}
+func postFinalRankingSettings(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		eliminatedRanking := c.FormValue("eliminatedRanking")
+		if validEliminatedRanking(eliminatedRanking) {
+			updateTournamentEliminatedRanking(db, tournamentID, eliminatedRanking)
+		}
+		return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID)
+	}
+}
+func postWithdrawTeam(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		teamID, _ := strconv.Atoi(c.Param("teamId"))
+		withdrawTeam(db, tournamentID, teamID)
+		return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID)
+	}
+}
+func adminTeamPlayers(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		teamID, _ := strconv.Atoi(c.Param("teamId"))
+		return c.Render(http.StatusOK, "admin/players", echo.Map{
+			"title":          "Joueurs",
+			"tournament":     selectTournament(db, tournamentID),
+			"team":           selectTeam(db, tournamentID, teamID),
+			"players":        selectTeamPlayers(db, tournamentID, teamID),
+			"playerHasGoals": c.FormValue("error") == "player_has_goals",
+		})
+	}
+}
+func postPlayer(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		teamID, _ := strconv.Atoi(c.Param("teamId"))
+		insertPlayer(db, tournamentID, player{
+			TeamID:    teamID,
+			Name:      strings.TrimSpace(c.FormValue("name")),
+			Number:    optionalIntParam(c, "number"),
+			LicenceID: strings.TrimSpace(c.FormValue("licenceId")),
+		})
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/tournaments/%s/teams/%d/players", tournamentID, teamID))
+	}
+}
+func removePlayer(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		teamID, _ := strconv.Atoi(c.Param("teamId"))
+		playerID, _ := strconv.Atoi(c.Param("playerId"))
+		url := fmt.Sprintf("/admin/tournaments/%s/teams/%d/players", tournamentID, teamID)
+		if countPlayerGoals(db, tournamentID, playerID) > 0 {
+			return c.Redirect(http.StatusSeeOther, url+"?error=player_has_goals")
+		}
+		deletePlayer(db, tournamentID, playerID)
+		return c.Redirect(http.StatusSeeOther, url)
+	}
+}
+func adminMatchGoals(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		sheet, err := loadMatchSheet(db, tournamentID, c.Param("ref"))
+		if err != nil {
+			return echo.ErrNotFound
+		}
+		return c.Render(http.StatusOK, "admin/goals", echo.Map{
+			"title":         "Buteurs",
+			"tournament":    selectTournament(db, tournamentID),
+			"match":         sheet,
+			"invalidGoals":  c.FormValue("error") == "invalid_goals",
+			"goalsMismatch": c.FormValue("error") == "goals_mismatch",
+		})
+	}
+}
+func postMatchGoals(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		ref := c.Param("ref")
+		sheet, err := loadMatchSheet(db, tournamentID, ref)
+		if err != nil {
+			return echo.ErrNotFound
+		}
+		url := "/admin/tournaments/" + tournamentID + "/matches/" + ref + "/goals"
+		goals, err := readGoals(sheet, c.FormValue)
+		if err == nil {
+			err = validateGoals(sheet, goals)
+		}
+		switch err {
+		case nil:
+		case errGoalsMismatch:
+			return c.Redirect(http.StatusSeeOther, url+"?error=goals_mismatch")
+		default:
+			return c.Redirect(http.StatusSeeOther, url+"?error=invalid_goals")
+		}
+		saveMatchGoals(db, tournamentID, ref, goals)
+		poolIndex, matchID, key, _ := parseMatchRef(ref)
+		if key != "" {
+			return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID+"/ranking-matches")
+		}
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/tournaments/%s/pools-matches#%d-%d", tournamentID, poolIndex, matchID))
+	}
+}
+func adminMatchCards(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		ref := c.Param("ref")
+		sheet, err := loadMatchSheet(db, tournamentID, ref)
+		if err != nil {
+			return echo.ErrNotFound
+		}
+		tournament := selectTournament(db, tournamentID)
+		cards := funk.Filter(selectTournamentCards(db, tournamentID), func(card card) bool {
+			return card.MatchRef == ref
+		}).([]card)
+		suspended := funk.Filter(tournamentSuspensions(db, tournament), func(suspension suspension) bool {
+			return suspension.MatchRef == ref
+		}).([]suspension)
+		return c.Render(http.StatusOK, "admin/cards", echo.Map{
+			"title":       "Cartons",
+			"tournament":  tournament,
+			"match":       sheet,
+			"cards":       cards,
+			"cardTypes":   cardTypes,
+			"suspensions": suspended,
+			"invalidCard": c.FormValue("error") == "invalid_card",
+		})
+	}
+}
+func postCard(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		ref := c.Param("ref")
+		sheet, err := loadMatchSheet(db, tournamentID, ref)
+		if err != nil {
+			return echo.ErrNotFound
+		}
+		url := "/admin/tournaments/" + tournamentID + "/matches/" + ref + "/cards"
+		teamID, _ := strconv.Atoi(c.FormValue("teamId"))
+		card := card{
+			MatchRef: ref,
+			TeamID:   teamID,
+			PlayerID: optionalIntParam(c, "playerId"),
+			Type:     c.FormValue("cardType"),
+			Minute:   optionalIntParam(c, "minute"),
+		}
+		if !validCard(sheet, card) {
+			return c.Redirect(http.StatusSeeOther, url+"?error=invalid_card")
+		}
+		insertCard(db, tournamentID, card)
+		return c.Redirect(http.StatusSeeOther, url)
+	}
+}
+func removeCard(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		cardID, _ := strconv.Atoi(c.Param("cardId"))
+		deleteCard(db, tournamentID, cardID)
+		return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID+"/matches/"+c.Param("ref")+"/cards")
+	}
+}
+func adminReferees(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		tournament := selectTournament(db, tournamentID)
+		matches := loadScheduledMatches(db, tournamentID)
+		sort.SliceStable(matches, func(i, j int) bool { return matches[i].Start.Before(matches[j].Start) })
+		officials := selectMatchOfficials(db, tournamentID)
+		referees := selectTournamentReferees(db, tournamentID)
+		return c.Render(http.StatusOK, "admin/referees", echo.Map{
+			"title":      "Arbitrage",
+			"tournament": tournament,
+			"referees":   referees,
+			"candidates": tournamentOfficials(referees, selectTournamentTeams(db, tournamentID)),
+			"matches":    matches,
+			"officials":  officials,
+			"conflicts":  refereeConflicts(matches, officials, tournamentScheduleSettings(tournament).GameDuration),
+		})
+	}
+}
+func postReferee(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		if name := strings.TrimSpace(c.FormValue("name")); name != "" {
+			insertReferee(db, tournamentID, name)
+		}
+		return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID+"/referees")
+	}
+}
+func removeReferee(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		refereeID, _ := strconv.Atoi(c.Param("refereeId"))
+		deleteReferee(db, tournamentID, refereeID)
+		return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID+"/referees")
+	}
+}
+func postAssignReferee(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		official, err := parseOfficialKey(c.FormValue("official"))
+		if err != nil {
+			return echo.ErrBadRequest
+		}
+		saveMatchOfficial(db, tournamentID, c.FormValue("ref"), official)
+		return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID+"/referees#"+c.FormValue("ref"))
+	}
+}
+func postAutoAssignReferees(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		tournament := selectTournament(db, tournamentID)
+		assignments := autoAssignReferees(
+			loadScheduledMatches(db, tournamentID),
+			selectMatchOfficials(db, tournamentID),
+			selectTournamentTeams(db, tournamentID),
+			tournamentScheduleSettings(tournament).GameDuration,
+		)
+		for ref, official := range assignments {
+			saveMatchOfficial(db, tournamentID, ref, official)
+		}
+		return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID+"/referees")
+	}
+}
+func adminRefereeSchedule(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		key := c.Param("official")
+		var name string
+		for _, candidate := range tournamentOfficials(selectTournamentReferees(db, tournamentID), selectTournamentTeams(db, tournamentID)) {
+			if candidate.Key() == key {
+				name = candidate.Name
+			}
+		}
+		if name == "" {
+			return echo.ErrNotFound
+		}
+		return c.Render(http.StatusOK, "admin/referee-schedule", echo.Map{
+			"title":      "Arbitrage " + name,
+			"tournament": selectTournament(db, tournamentID),
+			"name":       name,
+			"matches":    officialMatches(loadScheduledMatches(db, tournamentID), selectMatchOfficials(db, tournamentID), key),
+		})
+	}
+}
+func postMoveMatch(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		ref := c.FormValue("ref")
+		var day time.Time
+		for _, match := range loadScheduledMatches(db, tournamentID) {
+			if match.Ref == ref {
+				day = match.Start
+			}
+		}
+		scheduledAt, err := parseScheduleTime(c.FormValue("scheduledAt"), day)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid time "+c.FormValue("scheduledAt"))
+		}
+		pitchID, _ := strconv.Atoi(c.FormValue("pitchId"))
+		if funk.Find(selectTournamentPitches(db, tournamentID), func(p pitch) bool { return p.ID == pitchID }) == nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Unknown pitch "+c.FormValue("pitchId"))
+		}
+		settings := tournamentScheduleSettings(selectTournament(db, tournamentID))
+		matches := loadScheduledMatches(db, tournamentID)
+		moved, changed := moveMatch(matches, ref, scheduledAt, pitchID)
+		if len(changed) == 0 {
+			return echo.NewHTTPError(http.StatusNotFound, "Unknown match "+ref)
+		}
+		conflicts := append(rejectedChanges(matches, changed, false), newConflicts(validateSchedule(matches, settings), validateSchedule(moved, settings))...)
+		if len(conflicts) > 0 {
+			return renderSchedule(c, db, tournamentID, http.StatusConflict, conflicts)
+		}
+		updateMatchSlots(db, tournamentID, changed)
+		return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID+"/schedule")
+	}
+}
+func postShiftSchedule(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		minutes, err := strconv.Atoi(c.FormValue("minutes"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid minutes "+c.FormValue("minutes"))
+		}
+		tournament := selectTournament(db, tournamentID)
+		var from time.Time
+		if c.FormValue("from") != "" {
+			from, err = parseScheduleTime(c.FormValue("from"), parseScheduledAt(tournament.Date+" 00:00"))
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "Invalid time "+c.FormValue("from"))
+			}
+		}
+		settings := tournamentScheduleSettings(tournament)
+		matches := loadScheduledMatches(db, tournamentID)
+		shifted, changed := shiftMatches(matches, from, minutes)
+		conflicts := append(rejectedChanges(matches, changed, true), newConflicts(validateSchedule(matches, settings), validateSchedule(shifted, settings))...)
+		if len(conflicts) > 0 {
+			return renderSchedule(c, db, tournamentID, http.StatusConflict, conflicts)
+		}
+		updateMatchSlots(db, tournamentID, changed)
+		return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID+"/schedule")
+	}
+}
+func postTournamentStatus(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		status := c.FormValue("status")
+		if funk.Find(tournamentStatuses, func(s tournamentStatus) bool { return s.Value == status }) == nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Unknown status "+status)
+		}
+		// A draft only leaves the draft status through publishTournament, once the checklist is validated.
+		if status != statusDraft && selectTournament(db, tournamentID).Status == statusDraft {
+			return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID+"?error=checklist")
+		}
+		updateTournamentStatus(db, tournamentID, status)
 		return c.Redirect(http.StatusSeeOther, "/admin")
 	}
 }
 
 func createTournament(db *sql.DB) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		startTime, _ := time.Parse("15:04", c.FormValue("startTime"))
+		day, err := time.Parse("2006-01-02", c.FormValue("date"))
+		if err != nil {
+			day = time.Now()
+		}
+		startTime, _ := parseScheduleTime(c.FormValue("startTime"), day)
 		gameDuration, _ := time.ParseDuration(c.FormValue("gameDurationMinutes") + "m")
 		betweenGamesDuration, _ := time.ParseDuration(c.FormValue("betweenGamesDurationMinutes") + "m")
+		minRestMinutes, _ := strconv.Atoi(c.FormValue("minRestMinutes"))
 		tournamentID := c.FormValue("id")
 		tournamentName := c.FormValue("name")
 		nbTeams, _ := strconv.Atoi(c.FormValue("nbTeams"))
 		nbPools, _ := strconv.Atoi(c.FormValue("nbPools"))
+		nbPitches, _ := strconv.Atoi(c.FormValue("nbPitches"))
+		if nbPitches < 1 {
+			nbPitches = nbPools
+		}
 		pointsPerWin, _ := strconv.ParseFloat(c.FormValue("pointsPerWin"), 64)
 		pointsPerDraw, _ := strconv.ParseFloat(c.FormValue("pointsPerDraw"), 64)
 		pointsPerDefeat, _ := strconv.ParseFloat(c.FormValue("pointsPerDefeat"), 64)
 		pointsPerGoal, _ := strconv.ParseFloat(c.FormValue("pointsPerGoal"), 64)
+		locale := c.FormValue("locale")
+		if !supportedLocale(locale) {
+			locale = defaultLocale
+		}
+		scoring, ok := findScoringModel(c.FormValue("scoringModel"))
+		if !ok {
+			scoring = goalsScoring{}
+		}
 		tournament := tournament{
-			ID:              tournamentID,
-			Name:            tournamentName,
-			pointsPerWin:    pointsPerWin,
-			pointsPerDraw:   pointsPerDraw,
-			pointsPerDefeat: pointsPerDefeat,
-			pointsPerGoal:   pointsPerGoal,
+			ID:                  tournamentID,
+			Name:                tournamentName,
+			pointsPerWin:        pointsPerWin,
+			pointsPerDraw:       pointsPerDraw,
+			pointsPerDefeat:     pointsPerDefeat,
+			pointsPerGoal:       pointsPerGoal,
+			Status:              statusDraft,
+			GameDurationMinutes: int(gameDuration.Minutes()),
+			MinRestMinutes:      minRestMinutes,
+			PlayingWindows:      c.FormValue("playingWindows"),
+			Locale:              locale,
+			ScoringModel:        scoring.Name(),
+			Date:                startTime.Format("2006-01-02"),
+			Normalisation:       normalisationWeakest,
 		}
 		insertTournament(db, tournament)
 
+		pitches := make([]pitch, 0)
+		for pitchIndex := 1; pitchIndex <= nbPitches; pitchIndex++ {
+			pitches = append(pitches, pitch{ID: pitchIndex, Name: strconv.Itoa(pitchIndex)})
+		}
+		insertPitches(db, tournamentID, pitches)
+
 		nbTeamsPerPool := nbTeams / nbPools
 		nbTeamsToDispatch := nbTeams % nbPools
 
@@ -396,10 +1249,12 @@ func createTournament(db *sql.DB) echo.HandlerFunc {
 			currentPool := pool{
 				TournamentID: tournamentID,
 				Index:        poolIndex,
+				Name:         string(rune('A' + poolIndex - 1)),
 			}
 			poolTeams := make([]team, 0)
 			for j := 1; j <= poolSize; j++ {
 				team := team{
+					ID:        teamIndex,
 					Name:      fmt.Sprintf("Team %d", teamIndex),
 					PoolIndex: poolIndex,
 				}
@@ -412,9 +1267,11 @@ func createTournament(db *sql.DB) echo.HandlerFunc {
 			pairs := roundRobin(poolTeams)
 			matches := make([]poolMatch, 0)
 			matchTime := startTime
-			for _, pair := range pairs {
+			for matchIndex, pair := range pairs {
 				match := poolMatch{
+					ID:            matchIndex + 1,
 					PoolIndex:     poolIndex,
+					PitchID:       (poolIndex-1)%nbPitches + 1,
 					ScheduledAt:   matchTime,
 					HomeTeamID:    pair.Home.ID,
 					VisitorTeamID: pair.Visitor.ID,
@@ -428,6 +1285,30 @@ func createTournament(db *sql.DB) echo.HandlerFunc {
 		return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID)
 	}
 }
+func duplicateTournament(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		sourceID := c.Param("id")
+		tournamentID := strings.TrimSpace(c.FormValue("id"))
+		if tournamentID == "" || !tournamentExists(db, sourceID) {
+			return c.Redirect(http.StatusSeeOther, "/admin?error=invalid_duplicate")
+		}
+		if tournamentExists(db, tournamentID) {
+			return c.Redirect(http.StatusSeeOther, "/admin?error=duplicate_id")
+		}
+		sourceStart := selectTournamentStartTime(db, sourceID)
+		start := sourceStart
+		if date, err := time.Parse("2006-01-02", c.FormValue("date")); err == nil {
+			start = time.Date(date.Year(), date.Month(), date.Day(), start.Hour(), start.Minute(), 0, 0, time.UTC)
+		}
+		if startTime := c.FormValue("startTime"); startTime != "" {
+			start, _ = parseScheduleTime(startTime, start)
+		}
+		offset := start.Sub(sourceStart)
+		keepTeamNames := c.FormValue("keepTeamNames") == "on"
+		cloneTournament(db, sourceID, tournamentID, c.FormValue("name"), keepTeamNames, offset)
+		return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID)
+	}
+}
 func postTeamNames(db *sql.DB) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		tournamentID := c.Param("id")
@@ -437,7 +1318,7 @@ func postTeamNames(db *sql.DB) echo.HandlerFunc {
 			team.Name = c.FormValue(fmt.Sprintf("team_%d", team.ID))
 			updatedTeams = append(updatedTeams, team)
 		}
-		updateTeamNames(db, updatedTeams)
+		updateTeamNames(db, tournamentID, updatedTeams)
 		return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID)
 	}
 
@@ -447,16 +1328,25 @@ func postPoolMatchScore(db *sql.DB) echo.HandlerFunc {
 		tournamentID := c.Param("tournamentId")
 		matchID, _ := strconv.Atoi(c.Param("matchId"))
 		poolIndex, _ := strconv.Atoi(c.Param("poolIndex"))
-		homeTeamGoals, _ := strconv.Atoi(c.FormValue("homeTeamGoals"))
-		visitorTeamGoals, _ := strconv.Atoi(c.FormValue("visitorTeamGoals"))
-		savePoolMatchScore(db, tournamentID, matchID, homeTeamGoals, visitorTeamGoals)
-		matchesToBePlayed := countPoolMatchesToBePlayed(db, tournamentID, poolIndex)
-		if matchesToBePlayed == 0 {
-			teamRanking := selectTournamentPoolRanking(db, tournamentID, poolIndex)
-			for _, teamRank := range teamRanking {
-				updateRankingMatchFromPoolRank(db, tournamentID, poolIndex, teamRank.Rank, teamRank.ID)
-			}
+		status := c.FormValue("resultStatus")
+		if !validResultStatus(status, resultStatuses) {
+			status = resultPlayed
+		}
+		tournament := selectTournament(db, tournamentID)
+		homeTeamGoals, visitorTeamGoals, sets, err := tournamentScoringModel(tournament).ParseScore(
+			c.FormValue("homeTeamGoals"), c.FormValue("visitorTeamGoals"), c.FormValue("sets"))
+		switch {
+		case status == resultHomeForfeit || status == resultVisitorForfeit || status == resultDoubleForfeit:
+			homeTeamGoals, visitorTeamGoals = resultScore(status, tournament.ForfeitGoals, 0, 0)
+			sets = nil
+		case err != nil && status != resultAbandoned:
+			return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID+"/pools-matches?error=invalid_score#"+c.FormValue("anchor"))
 		}
+		before := loadWebhookState(db, tournamentID)
+		savePoolMatchScore(db, tournamentID, poolIndex, matchID, status, homeTeamGoals, visitorTeamGoals)
+		saveMatchSets(db, tournamentID, poolMatchRef(poolIndex, matchID), sets)
+		completePool(db, tournamentID, poolIndex)
+		fireWebhooks(db, tournamentID, poolMatchRef(poolIndex, matchID), before)
 		return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID+"/pools-matches#"+c.FormValue("anchor"))
 	}
 }
@@ -464,32 +1354,476 @@ func postRankingMatchScore(db *sql.DB) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		tournamentID := c.Param("tournamentId")
 		key := c.Param("key")
-		homeTeamGoals, _ := strconv.Atoi(c.FormValue("homeTeamGoals"))
-		visitorTeamGoals, _ := strconv.Atoi(c.FormValue("visitorTeamGoals"))
-		penaltyShootOutWinner := c.FormValue("penaltyShootOutWinner")
-		if !validRankingMatchScore(homeTeamGoals, visitorTeamGoals, penaltyShootOutWinner) {
+		status := c.FormValue("resultStatus")
+		before := loadWebhookState(db, tournamentID)
+		if status != "" && status != resultPlayed {
+			if !validResultStatus(status, rankingMatchResultStatuses) {
+				return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID+"/ranking-matches?error=invalid_score")
+			}
+			homeTeamGoals, visitorTeamGoals := resultScore(status, selectTournament(db, tournamentID).ForfeitGoals, 0, 0)
+			recordRankingMatchResult(db, tournamentID, key, status, rankingMatchScore{HomeTeamGoals: homeTeamGoals, VisitorTeamGoals: visitorTeamGoals})
+			saveMatchSets(db, tournamentID, rankingMatchRef(key), nil)
+			applyWithdrawals(db, tournamentID)
+			fireWebhooks(db, tournamentID, rankingMatchRef(key), before)
+			return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID+"/ranking-matches")
+		}
+		scoring := tournamentScoringModel(selectTournament(db, tournamentID))
+		if scoring.Name() != scoringGoals {
+			homeScore, visitorScore, sets, err := scoring.ParseScore(c.FormValue("homeTeamGoals"), c.FormValue("visitorTeamGoals"), c.FormValue("sets"))
+			if err != nil || homeScore == visitorScore {
+				return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID+"/ranking-matches?error=invalid_score")
+			}
+			recordRankingMatchResult(db, tournamentID, key, resultPlayed, rankingMatchScore{HomeTeamGoals: homeScore, VisitorTeamGoals: visitorScore})
+			saveMatchSets(db, tournamentID, rankingMatchRef(key), sets)
+			applyWithdrawals(db, tournamentID)
+			fireWebhooks(db, tournamentID, rankingMatchRef(key), before)
+			return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID+"/ranking-matches")
+		}
+		homeTeamGoals, err1 := strconv.Atoi(c.FormValue("homeTeamGoals"))
+		visitorTeamGoals, err2 := strconv.Atoi(c.FormValue("visitorTeamGoals"))
+		score := rankingMatchScore{
+			HomeTeamGoals:             homeTeamGoals,
+			VisitorTeamGoals:          visitorTeamGoals,
+			HomeTeamExtraTimeGoals:    optionalIntParam(c, "homeTeamExtraTimeGoals"),
+			VisitorTeamExtraTimeGoals: optionalIntParam(c, "visitorTeamExtraTimeGoals"),
+			HomeTeamPenaltyGoals:      optionalIntParam(c, "homeTeamPenaltyGoals"),
+			VisitorTeamPenaltyGoals:   optionalIntParam(c, "visitorTeamPenaltyGoals"),
+		}
+		if err1 != nil || err2 != nil || !score.Valid() {
 			return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID+"/ranking-matches?error=invalid_score")
 		}
-		homeTeamID, visitorTeamID := selectRankingMatchTeamIDs(db, tournamentID, key)
-		var winnerTeamID int
-		var looserTeamID int
-		if homeTeamGoals > visitorTeamGoals || penaltyShootOutWinner == "home" {
-			winnerTeamID = homeTeamID
-			looserTeamID = visitorTeamID
-		} else if homeTeamGoals < visitorTeamGoals || penaltyShootOutWinner == "visitor" {
-			winnerTeamID = visitorTeamID
-			looserTeamID = homeTeamID
-		}
-		saveRankingMatchScore(db, tournamentID, key, homeTeamGoals, visitorTeamGoals, winnerTeamID, looserTeamID)
-		updateRankingMatchFromSourceRankingMatch(db, tournamentID, key, winnerTeamID, looserTeamID)
+		recordRankingMatchResult(db, tournamentID, key, resultPlayed, score)
+		applyWithdrawals(db, tournamentID)
+		fireWebhooks(db, tournamentID, rankingMatchRef(key), before)
 		return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID+"/ranking-matches")
 	}
 }
 
-func validRankingMatchScore(homeTeamGoals int, visitorTeamGoals int, penaltyShootOutWinner string) bool {
-	if homeTeamGoals == visitorTeamGoals {
-		return penaltyShootOutWinner == "home" || penaltyShootOutWinner == "visitor"
-	} else {
-		return penaltyShootOutWinner == "none"
+func adminPrintouts(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		if !tournamentExists(db, tournamentID) {
+			return echo.ErrNotFound
+		}
+		tournament := selectTournament(db, tournamentID)
+		matches := loadDisplayMatches(db, tournament, tournament.Locale)
+		sort.SliceStable(matches, func(i, j int) bool { return matches[i].Start.Before(matches[j].Start) })
+		return c.Render(http.StatusOK, "admin/printouts", echo.Map{
+			"title":          "Impressions",
+			"tournament":     tournament,
+			"matches":        matches,
+			"pitches":        selectTournamentPitches(db, tournamentID),
+			"pools":          selectTournamentPools(db, tournamentID),
+			"ranking":        loadFinalRanking(db, tournamentID),
+			"nothingToPrint": c.FormValue("error") == "nothing_to_print",
+		})
+	}
+}
+
+// getPrintout generates a PDF document. Query parameters narrow it to a match, a pitch, a pool or a rank,
+// all of them being printed otherwise, and add the rosters to match sheets.
+func getPrintout(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		if !tournamentExists(db, tournamentID) {
+			return echo.ErrNotFound
+		}
+		tournament := selectTournament(db, tournamentID)
+		document := c.Param("document")
+		rosters := c.QueryParam("rosters") == "on"
+		source := loadPrintoutSource(db, tournament, rosters)
+		pitchID, _ := strconv.Atoi(c.QueryParam("pitch"))
+		poolIndex, _ := strconv.Atoi(c.QueryParam("pool"))
+		rank, _ := strconv.Atoi(c.QueryParam("rank"))
+		p := newPrintout(tournament.Locale)
+		var err error
+		switch document {
+		case printMatchSheets:
+			err = p.addMatchSheets(source, c.QueryParam("ref"), rosters)
+		case printPitchSchedules:
+			err = p.addPitchSchedules(source, pitchID)
+		case printPoolSchedules:
+			err = p.addPoolSchedules(source, poolIndex)
+		case printCertificates:
+			err = p.addCertificates(source, rank)
+		case printAll:
+			err = p.addAll(source, rosters)
+		default:
+			return echo.ErrNotFound
+		}
+		if err == errNothingToPrint {
+			return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID+"/printouts?error=nothing_to_print")
+		}
+		c.Response().Header().Set(echo.HeaderContentType, "application/pdf")
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", tournamentID+"-"+document+".pdf"))
+		return p.pdf.Output(c.Response())
+	}
+}
+
+// getExport downloads a table of the tournament as CSV, or as a spreadsheet with format=xlsx. The complete
+// workbook only exists as a spreadsheet.
+func getExport(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		if !tournamentExists(db, tournamentID) {
+			return echo.ErrNotFound
+		}
+		name := c.Param("table")
+		var table exportTable
+		var sheets []exportSheet
+		switch name {
+		case exportPoolMatches:
+			table = poolMatchesTable(loadAllPoolsMatches(db, tournamentID, NullTime{}, NullTime{}))
+			sheets = []exportSheet{{Name: "Matchs de poule", Tables: []exportTable{table}}}
+		case exportRankingMatches:
+			table = rankingMatchesTable(tournamentRankingMatches(db, tournamentID, "fr", NullTime{}, NullTime{}))
+			sheets = []exportSheet{{Name: "Matchs de classement", Tables: []exportTable{table}}}
+		case exportPoolRankings:
+			rankings := loadAllTournamentPoolsRanking(db, tournamentID)
+			table = poolRankingTable(rankings, true)
+			for _, ranking := range rankings {
+				sheets = append(sheets, exportSheet{Name: "Poule " + ranking.PoolName, Tables: []exportTable{poolRankingTable([]rankingViewModel{ranking}, false)}})
+			}
+		case exportFinalRanking:
+			table = finalRankingTable(loadFinalRanking(db, tournamentID))
+			sheets = []exportSheet{{Name: "Classement final", Tables: []exportTable{table}}}
+		case exportAll:
+			sheets = exportWorkbook(db, tournamentID)
+		default:
+			return echo.ErrNotFound
+		}
+		filename := tournamentID + "-" + name
+		if c.QueryParam("format") == "xlsx" {
+			c.Response().Header().Set(echo.HeaderContentType, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+			c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename+".xlsx"))
+			return writeWorkbook(c.Response(), sheets)
+		}
+		if name == exportAll {
+			return echo.ErrNotFound
+		}
+		c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename+".csv"))
+		return writeCSV(c.Response(), table)
+	}
+}
+
+// adminScorekeeper is the phone view of the scorekeeper of a pitch, listing its next matches.
+func adminScorekeeper(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		if !tournamentExists(db, tournamentID) {
+			return echo.ErrNotFound
+		}
+		tournament := selectTournament(db, tournamentID)
+		pitchID, _ := strconv.Atoi(c.QueryParam("pitch"))
+		next, played := scorekeeperMatches(loadDisplayMatches(db, tournament, tournament.Locale), pitchID)
+		return c.Render(http.StatusOK, "admin/scorekeeper", echo.Map{
+			"title":      "Table de marque",
+			"tournament": tournament,
+			"pitches":    selectTournamentPitches(db, tournamentID),
+			"pitchID":    pitchID,
+			"next":       next,
+			"played":     played,
+			"scoring":    tournamentScoringModel(tournament),
+		})
+	}
+}
+
+// postScoreSubmission saves a score sent as JSON by the scorekeeper view. A submission already received
+// is acknowledged without being applied again, so that phones can safely replay their queue. The submission
+// is claimed before being applied, and released when it cannot be.
+func postScoreSubmission(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		if !tournamentExists(db, tournamentID) {
+			return echo.ErrNotFound
+		}
+		var submission scoreSubmission
+		if err := c.Bind(&submission); err != nil || !submission.Valid() {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid_submission"})
+		}
+		if !claimScoreSubmission(db, tournamentID, submission.SubmissionID, submission.Ref, time.Now()) {
+			return c.JSON(http.StatusOK, echo.Map{"status": "duplicate"})
+		}
+		applied := false
+		defer func() {
+			if !applied {
+				releaseScoreSubmission(db, submission.SubmissionID)
+			}
+		}()
+		switch err := applyScoreSubmission(db, selectTournament(db, tournamentID), submission); err {
+		case nil:
+		case errUnknownMatch:
+			return c.JSON(http.StatusNotFound, echo.Map{"error": "unknown_match"})
+		default:
+			return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": "invalid_score"})
+		}
+		applied = true
+		return c.JSON(http.StatusOK, echo.Map{"status": "saved"})
+	}
+}
+
+// postLiveAction starts, pauses, resumes or finishes a match played live, or changes its running score.
+// Like score submissions, actions already received are acknowledged without being applied again.
+func postLiveAction(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		if !tournamentExists(db, tournamentID) {
+			return echo.ErrNotFound
+		}
+		var submission liveSubmission
+		if err := c.Bind(&submission); err != nil || !submission.Valid() {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid_submission"})
+		}
+		now := time.Now()
+		if !claimScoreSubmission(db, tournamentID, submission.SubmissionID, submission.Ref, now) {
+			return c.JSON(http.StatusOK, echo.Map{"status": "duplicate"})
+		}
+		applied := false
+		defer func() {
+			if !applied {
+				releaseScoreSubmission(db, submission.SubmissionID)
+			}
+		}()
+		switch err := applyLiveAction(db, selectTournament(db, tournamentID), submission.Ref, submission.Action, submission.Time(now)); err {
+		case nil:
+		case errUnknownMatch:
+			return c.JSON(http.StatusNotFound, echo.Map{"error": "unknown_match"})
+		case errInvalidLiveAction:
+			return c.JSON(http.StatusConflict, echo.Map{"error": "invalid_action"})
+		default:
+			return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": "invalid_score"})
+		}
+		applied = true
+		return c.JSON(http.StatusOK, echo.Map{"status": "saved"})
+	}
+}
+
+func adminWebhooks(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		if !tournamentExists(db, tournamentID) {
+			return echo.ErrNotFound
+		}
+		return c.Render(http.StatusOK, "admin/webhooks", echo.Map{
+			"title":          "Webhooks",
+			"tournament":     selectTournament(db, tournamentID),
+			"webhooks":       selectTournamentWebhooks(db, tournamentID),
+			"deliveries":     selectTournamentWebhookDeliveries(db, tournamentID, 100),
+			"events":         webhookEvents,
+			"invalidWebhook": c.FormValue("error") == "invalid_webhook",
+		})
+	}
+}
+
+// postWebhook subscribes a URL to events of the tournament, a secret being generated when none is given.
+func postWebhook(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		if !tournamentExists(db, tournamentID) {
+			return echo.ErrNotFound
+		}
+		form, _ := c.FormParams()
+		w := webhook{
+			TournamentID: tournamentID,
+			URL:          strings.TrimSpace(c.FormValue("url")),
+			Secret:       strings.TrimSpace(c.FormValue("secret")),
+			Events:       form["events"],
+		}
+		target, err := url.Parse(w.URL)
+		valid := err == nil && (target.Scheme == "http" || target.Scheme == "https") && target.Host != "" && len(w.Events) > 0
+		for _, event := range w.Events {
+			valid = valid && validWebhookEvent(event)
+		}
+		if !valid {
+			return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID+"/webhooks?error=invalid_webhook")
+		}
+		if w.Secret == "" {
+			w.Secret = newWebhookSecret()
+		}
+		insertWebhook(db, w)
+		return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID+"/webhooks")
+	}
+}
+func removeWebhook(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		webhookID, _ := strconv.Atoi(c.Param("webhookId"))
+		deleteWebhook(db, tournamentID, webhookID)
+		return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID+"/webhooks")
+	}
+}
+func postRetryWebhookDelivery(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tournamentID := c.Param("id")
+		deliveryID, _ := strconv.Atoi(c.Param("deliveryId"))
+		retryWebhookDelivery(db, tournamentID, deliveryID, time.Now())
+		return c.Redirect(http.StatusSeeOther, "/admin/tournaments/"+tournamentID+"/webhooks")
+	}
+}
+
+func adminDisplays(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return c.Render(http.StatusOK, "admin/displays", echo.Map{
+			"title":    "Écrans",
+			"displays": selectDisplays(db),
+			"locales":  locales,
+		})
+	}
+}
+func postDisplay(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		name := strings.TrimSpace(c.FormValue("name"))
+		if name == "" || !supportedLocale(c.FormValue("locale")) {
+			return echo.ErrBadRequest
+		}
+		displayID := insertDisplay(db, name, c.FormValue("locale"))
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/displays/%d", displayID))
+	}
+}
+func adminDisplay(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		displayID, _ := strconv.Atoi(c.Param("id"))
+		display, err := selectDisplay(db, displayID)
+		if err == sql.ErrNoRows {
+			return echo.ErrNotFound
+		} else if err != nil {
+			panic(err)
+		}
+		return c.Render(http.StatusOK, "admin/display", echo.Map{
+			"title":           "Écran",
+			"display":         display,
+			"slides":          selectDisplaySlides(db, displayID),
+			"tournaments":     selectTournaments(db),
+			"locales":         locales,
+			"slideKinds":      slideKinds,
+			"invalidSettings": c.FormValue("error") == "invalid_settings",
+			"invalidSlide":    c.FormValue("error") == "invalid_slide",
+		})
+	}
+}
+func postDisplaySettings(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		displayID, _ := strconv.Atoi(c.Param("id"))
+		display, err := selectDisplay(db, displayID)
+		if err == sql.ErrNoRows {
+			return echo.ErrNotFound
+		} else if err != nil {
+			panic(err)
+		}
+		var err1, err2, err3 error
+		display.Name = strings.TrimSpace(c.FormValue("name"))
+		display.Locale = c.FormValue("locale")
+		display.SlideSeconds, err1 = strconv.Atoi(c.FormValue("slideSeconds"))
+		display.UpcomingMinutes, err2 = strconv.Atoi(c.FormValue("upcomingMinutes"))
+		display.LatestResults, err3 = strconv.Atoi(c.FormValue("latestResults"))
+		if err1 != nil || err2 != nil || err3 != nil || display.Name == "" || !supportedLocale(display.Locale) ||
+			display.SlideSeconds < 1 || display.UpcomingMinutes < 1 || display.LatestResults < 1 {
+			return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/displays/%d?error=invalid_settings", displayID))
+		}
+		form, _ := c.FormParams()
+		display.TournamentIDs = form["tournaments"]
+		updateDisplay(db, display)
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/displays/%d", displayID))
+	}
+}
+func removeDisplay(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		displayID, _ := strconv.Atoi(c.Param("id"))
+		deleteDisplay(db, displayID)
+		return c.Redirect(http.StatusSeeOther, "/admin/displays")
+	}
+}
+func postDisplaySlide(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		displayID, _ := strconv.Atoi(c.Param("id"))
+		slide := displaySlide{
+			Kind:     c.FormValue("kind"),
+			ImageURL: strings.TrimSpace(c.FormValue("imageUrl")),
+			Seconds:  optionalIntParam(c, "seconds"),
+		}
+		if !validSlideKind(slide.Kind) || (slide.Kind == slideSponsor && slide.ImageURL == "") || (slide.Seconds.Valid && slide.Seconds.Int64 < 1) {
+			return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/displays/%d?error=invalid_slide", displayID))
+		}
+		if slide.Kind != slideSponsor {
+			slide.ImageURL = ""
+		}
+		insertDisplaySlide(db, displayID, slide)
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/displays/%d", displayID))
+	}
+}
+func removeDisplaySlide(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		displayID, _ := strconv.Atoi(c.Param("id"))
+		slideID, _ := strconv.Atoi(c.Param("slideId"))
+		deleteDisplaySlide(db, displayID, slideID)
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/displays/%d", displayID))
+	}
+}
+
+// getDisplay renders one page of the rotation of a display, the page reloading itself on the next one so
+// that the big screen always shows live data. Draft and deleted tournaments are left out.
+func getDisplay(db *sql.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		displayID, _ := strconv.Atoi(c.Param("id"))
+		display, err := selectDisplay(db, displayID)
+		if err == sql.ErrNoRows {
+			return echo.ErrNotFound
+		} else if err != nil {
+			panic(err)
+		}
+		locale := requestLocale(c, display.Locale)
+		tournaments := make(map[string]tournament)
+		tournamentIDs := make([]string, 0)
+		for _, tournamentID := range display.TournamentIDs {
+			if tournament, err := loadPublicTournament(db, tournamentID); err == nil {
+				tournaments[tournamentID] = tournament
+				tournamentIDs = append(tournamentIDs, tournamentID)
+			}
+		}
+		pages := displayPages(selectDisplaySlides(db, displayID), tournamentIDs)
+		data := echo.Map{
+			"title":   display.Name,
+			"locale":  locale,
+			"display": display,
+			"wide":    true,
+			"refresh": displayRefresh{Seconds: display.SlideSeconds, URL: fmt.Sprintf("/displays/%d", displayID)},
+		}
+		if len(pages) == 0 {
+			return c.Render(http.StatusOK, "display", data)
+		}
+		index, _ := strconv.Atoi(c.QueryParam("page"))
+		index = (index%len(pages) + len(pages)) % len(pages)
+		page := pages[index]
+		data["page"] = page
+		data["refresh"] = displayRefresh{Seconds: display.Seconds(page), URL: fmt.Sprintf("/displays/%d?page=%d", displayID, index+1)}
+		switch page.Slide.Kind {
+		case slidePoolsRanking:
+			tournament := tournaments[page.TournamentID]
+			data["tournament"] = tournament
+			data["scoring"] = tournamentScoringModel(tournament)
+			data["pools"] = loadAllTournamentPoolsRanking(db, page.TournamentID)
+		case slideBracket:
+			data["tournament"] = tournaments[page.TournamentID]
+			data["brackets"] = rankingBrackets(tournamentRankingMatches(db, page.TournamentID, locale, NullTime{}, NullTime{}))
+		case slideUpcomingMatches, slideLatestResults:
+			matches := make([]displayMatch, 0)
+			for _, tournamentID := range tournamentIDs {
+				matches = append(matches, loadDisplayMatches(db, tournaments[tournamentID], locale)...)
+			}
+			data["pitches"] = upcomingMatches(matches, time.Now(), display.UpcomingMinutes)
+			data["results"] = latestResults(matches, display.LatestResults)
+			data["manyTournaments"] = len(tournamentIDs) > 1
+		}
+		return c.Render(http.StatusOK, "display", data)
+	}
+}
+
+// optionalIntParam returns a NULL integer when the form value is left empty.
+func optionalIntParam(c echo.Context, name string) sql.NullInt64 {
+	value, err := strconv.Atoi(c.FormValue(name))
+	if err != nil {
+		return sql.NullInt64{}
 	}
+	return sql.NullInt64{Int64: int64(value), Valid: true}
 }
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+)
+
+// teamMatch is a pool or ranking match as shown on the page of one of its teams.
+type teamMatch struct {
+	Ref             string
+	Label           string
+	ScheduledAt     time.Time
+	PitchName       string
+	HomeTeamName    string
+	VisitorTeamName string
+	Score           string
+	Played          bool
+	Next            bool
+}
+
+// bracketStep is a ranking match of the team, Won being meaningful once the match is played.
+type bracketStep struct {
+	Match  rankingMatch
+	Played bool
+	Won    bool
+}
+
+// bracketPath is the way of a team through the ranking matches: the matches it played or is about to play,
+// then where a win or a defeat in its last match leads, either another match or a final rank.
+type bracketPath struct {
+	Steps  []bracketStep
+	IfWin  sql.NullString
+	IfLose sql.NullString
+	// WinRank, LoseRank and FinalRank are final ranks, zero when there is none.
+	WinRank   int
+	LoseRank  int
+	FinalRank int
+}
+
+// teamMatches merges the pool and ranking matches of the team in chronological order and flags the next
+// one to be played.
+func teamMatches(locale string, teamID int, pool pool, poolMatches []poolMatch, rankingMatches []rankingMatch) []teamMatch {
+	slice := make([]teamMatch, 0)
+	for _, match := range poolMatches {
+		if match.HomeTeamID != teamID && match.VisitorTeamID != teamID {
+			continue
+		}
+		slice = append(slice, teamMatch{
+			Ref:             poolMatchRef(match.PoolIndex, match.ID),
+			Label:           translate(locale, "pool", pool.Name),
+			ScheduledAt:     match.ScheduledAt,
+			PitchName:       match.PitchName,
+			HomeTeamName:    match.HomeTeamName,
+			VisitorTeamName: match.VisitorTeamName,
+			Score:           match.Score(locale),
+			Played:          match.Played(),
+		})
+	}
+	for _, match := range rankingMatches {
+		if int(match.HomeTeamID.Int64) != teamID && int(match.VisitorTeamID.Int64) != teamID {
+			continue
+		}
+		slice = append(slice, teamMatch{
+			Ref:             rankingMatchRef(match.Key),
+			Label:           translate(locale, "match") + " " + match.Key,
+			ScheduledAt:     match.ScheduledAt,
+			PitchName:       match.PitchName,
+			HomeTeamName:    match.HomeTeamName.String,
+			VisitorTeamName: match.VisitorTeamName.String,
+			Score:           match.Score(locale),
+			Played:          match.Played(),
+		})
+	}
+	sort.SliceStable(slice, func(i, j int) bool { return slice[i].ScheduledAt.Before(slice[j].ScheduledAt) })
+	for i := range slice {
+		if !slice[i].Played {
+			slice[i].Next = true
+			break
+		}
+	}
+	return slice
+}
+
+// teamBracketPath follows the team through the ranking matches. The path is empty until the pool of the
+// team is over and the team is sent to its first ranking match.
+func teamBracketPath(teamID int, matches []rankingMatch) bracketPath {
+	path := bracketPath{}
+	for _, match := range matches {
+		if int(match.HomeTeamID.Int64) != teamID && int(match.VisitorTeamID.Int64) != teamID {
+			continue
+		}
+		path.Steps = append(path.Steps, bracketStep{
+			Match:  match,
+			Played: match.WinnerTeamID.Valid,
+			Won:    int(match.WinnerTeamID.Int64) == teamID,
+		})
+	}
+	if len(path.Steps) == 0 {
+		return path
+	}
+	sort.SliceStable(path.Steps, func(i, j int) bool {
+		return path.Steps[i].Match.ScheduledAt.Before(path.Steps[j].Match.ScheduledAt)
+	})
+	last := path.Steps[len(path.Steps)-1]
+	if last.Played {
+		if last.Won {
+			path.FinalRank = int(last.Match.WinnerFinalRank.Int64)
+		} else {
+			path.FinalRank = int(last.Match.LooserFinalRank.Int64)
+		}
+		return path
+	}
+	for _, match := range matches {
+		sources := []struct {
+			key    sql.NullString
+			winner sql.NullBool
+		}{
+			{match.HomeTeamSourceRankingMatch, match.HomeTeamSourceRankingMatchWinner},
+			{match.VisitorTeamSourceRankingMatch, match.VisitorTeamSourceRankingMatchWinner},
+		}
+		for _, source := range sources {
+			if !source.key.Valid || source.key.String != last.Match.Key {
+				continue
+			}
+			if source.winner.Bool {
+				path.IfWin = sql.NullString{String: match.Key, Valid: true}
+			} else {
+				path.IfLose = sql.NullString{String: match.Key, Valid: true}
+			}
+		}
+	}
+	if !path.IfWin.Valid {
+		path.WinRank = int(last.Match.WinnerFinalRank.Int64)
+	}
+	if !path.IfLose.Valid {
+		path.LoseRank = int(last.Match.LooserFinalRank.Int64)
+	}
+	return path
+}
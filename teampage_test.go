@@ -0,0 +1,47 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func testRankingMatch(key string, scheduledAt string, homeTeamID int, visitorTeamID int) rankingMatch {
+	return rankingMatch{
+		Key:           key,
+		ScheduledAt:   parseTime(scheduledAt),
+		HomeTeamID:    nullInt(int64(homeTeamID)),
+		VisitorTeamID: nullInt(int64(visitorTeamID)),
+	}
+}
+
+func TestTeamBracketPath(t *testing.T) {
+	semiFinal := testRankingMatch("SF1", "10:00", 1, 2)
+	semiFinal.WinnerTeamID = nullInt(1)
+	final := testRankingMatch("F", "11:00", 1, 0)
+	final.HomeTeamSourceRankingMatch = sql.NullString{String: "SF1", Valid: true}
+	final.HomeTeamSourceRankingMatchWinner = sql.NullBool{Bool: true, Valid: true}
+	final.WinnerFinalRank = nullInt(1)
+	final.LooserFinalRank = nullInt(2)
+	path := teamBracketPath(1, []rankingMatch{final, semiFinal})
+	if len(path.Steps) != 2 || path.Steps[0].Match.Key != "SF1" || !path.Steps[0].Won {
+		t.Errorf("Expected the won semi-final then the final, got %+v.", path.Steps)
+	}
+	if path.WinRank != 1 || path.LoseRank != 2 || path.FinalRank != 0 {
+		t.Errorf("Expected the final to decide the first and second places, got %+v.", path)
+	}
+	if path := teamBracketPath(3, []rankingMatch{final, semiFinal}); len(path.Steps) != 0 {
+		t.Errorf("Expected no path for a team out of the bracket, got %+v.", path)
+	}
+}
+
+func TestTeamMatchesFlagsNextMatch(t *testing.T) {
+	matches := []poolMatch{
+		{ID: 2, PoolIndex: 1, ScheduledAt: parseTime("09:30"), HomeTeamID: 1, VisitorTeamID: 3},
+		{ID: 1, PoolIndex: 1, ScheduledAt: parseTime("09:00"), HomeTeamID: 1, VisitorTeamID: 2, HomeTeamGoals: nullInt(1), VisitorTeamGoals: nullInt(0)},
+		{ID: 3, PoolIndex: 1, ScheduledAt: parseTime("09:15"), HomeTeamID: 2, VisitorTeamID: 3},
+	}
+	slice := teamMatches("fr", 1, pool{Index: 1, Name: "A"}, matches, nil)
+	if len(slice) != 2 || slice[0].Next || !slice[1].Next || slice[0].Score != "1–0" {
+		t.Errorf("Expected the 09:30 match to be the next one, got %+v.", slice)
+	}
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	webhookScoreSaved         = "match.score_saved"
+	webhookPoolCompleted      = "pool.completed"
+	webhookTeamsResolved      = "ranking_match.teams_resolved"
+	webhookTournamentFinished = "tournament.finished"
+)
+
+type webhookEvent struct {
+	Value string
+	Label string
+}
+
+var webhookEvents = []webhookEvent{
+	{webhookScoreSaved, "Score enregistré"},
+	{webhookPoolCompleted, "Poule terminée"},
+	{webhookTeamsResolved, "Équipes d'un match de classement connues"},
+	{webhookTournamentFinished, "Tournoi terminé"},
+}
+
+func validWebhookEvent(event string) bool {
+	for _, e := range webhookEvents {
+		if e.Value == event {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	deliveryPending   = "pending"
+	deliveryDelivered = "delivered"
+	deliveryFailed    = "failed"
+)
+
+// webhookMaxAttempts is the number of attempts after which a delivery is given up, about four hours after
+// the event with the backoff below.
+const webhookMaxAttempts = 10
+
+// webhookTimeLayout stores times in UTC, so that they can be compared as strings in SQL.
+const webhookTimeLayout = "2006-01-02 15:04:05"
+
+// webhook is a subscription of a URL to events of a tournament.
+type webhook struct {
+	ID           int
+	TournamentID string
+	URL          string
+	Secret       string
+	Events       []string
+}
+
+func (w webhook) Subscribed(event string) bool {
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookDelivery is an event waiting to be posted to a webhook, or already posted, kept as a delivery log.
+type webhookDelivery struct {
+	ID             int
+	WebhookID      int
+	URL            string
+	Secret         string
+	Event          string
+	Payload        string
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode sql.NullInt64
+	LastError      string
+	CreatedAt      time.Time
+}
+
+type webhookPayload struct {
+	Event        string      `json:"event"`
+	TournamentID string      `json:"tournament_id"`
+	OccurredAt   time.Time   `json:"occurred_at"`
+	Data         interface{} `json:"data"`
+}
+
+type webhookMatch struct {
+	Ref              string `json:"ref"`
+	Label            string `json:"label"`
+	ScheduledAt      string `json:"scheduled_at"`
+	Pitch            string `json:"pitch"`
+	HomeTeam         string `json:"home_team"`
+	VisitorTeam      string `json:"visitor_team"`
+	HomeTeamGoals    *int64 `json:"home_team_goals"`
+	VisitorTeamGoals *int64 `json:"visitor_team_goals"`
+	Score            string `json:"score,omitempty"`
+}
+
+type webhookRankedTeam struct {
+	Rank   int      `json:"rank"`
+	Shared bool     `json:"shared,omitempty"`
+	Team   string   `json:"team"`
+	Points *float64 `json:"points,omitempty"`
+}
+
+type webhookPool struct {
+	PoolIndex int                 `json:"pool_index"`
+	Pool      string              `json:"pool"`
+	Ranking   []webhookRankedTeam `json:"ranking"`
+}
+
+type webhookFinalRanking struct {
+	Ranking []webhookRankedTeam `json:"ranking"`
+}
+
+// webhookState is what the events are derived from, by comparing it before and after a score is saved.
+type webhookState struct {
+	PoolMatchesToPlay map[int]int
+	ResolvedMatches   map[string]bool
+	Finished          bool
+}
+
+func webhookStateOf(poolMatches []poolMatch, rankingMatches []rankingMatch) webhookState {
+	state := webhookState{PoolMatchesToPlay: make(map[int]int), ResolvedMatches: make(map[string]bool)}
+	played := len(poolMatches)+len(rankingMatches) > 0
+	for _, match := range poolMatches {
+		if _, ok := state.PoolMatchesToPlay[match.PoolIndex]; !ok {
+			state.PoolMatchesToPlay[match.PoolIndex] = 0
+		}
+		if !match.Played() {
+			state.PoolMatchesToPlay[match.PoolIndex]++
+			played = false
+		}
+	}
+	for _, match := range rankingMatches {
+		state.ResolvedMatches[match.Key] = match.HomeTeamID.Int64 != 0 && match.VisitorTeamID.Int64 != 0
+		if !match.Played() {
+			played = false
+		}
+	}
+	state.Finished = played
+	return state
+}
+
+func loadWebhookState(db *sql.DB, tournamentID string) webhookState {
+	live := selectLiveMatches(db, tournamentID)
+	poolMatches := selectAllTournamentPoolMatches(db, tournamentID)
+	for i := range poolMatches {
+		poolMatches[i].Live = live[poolMatchRef(poolMatches[i].PoolIndex, poolMatches[i].ID)]
+	}
+	rankingMatches := selectTournamentRankingMatches(db, tournamentID, NullTime{}, NullTime{})
+	for i := range rankingMatches {
+		rankingMatches[i].Live = live[rankingMatchRef(rankingMatches[i].Key)]
+	}
+	return webhookStateOf(poolMatches, rankingMatches)
+}
+
+// webhookChanges lists the pools completed, the ranking matches whose teams got known and whether the
+// tournament finished between two states.
+type webhookChanges struct {
+	CompletedPools  []int
+	ResolvedMatches []string
+	Finished        bool
+}
+
+func webhookChangesBetween(before webhookState, after webhookState) webhookChanges {
+	changes := webhookChanges{}
+	for poolIndex, toPlay := range after.PoolMatchesToPlay {
+		if toPlay == 0 && before.PoolMatchesToPlay[poolIndex] > 0 {
+			changes.CompletedPools = append(changes.CompletedPools, poolIndex)
+		}
+	}
+	for key, resolved := range after.ResolvedMatches {
+		if resolved && !before.ResolvedMatches[key] {
+			changes.ResolvedMatches = append(changes.ResolvedMatches, key)
+		}
+	}
+	sort.Ints(changes.CompletedPools)
+	sort.Strings(changes.ResolvedMatches)
+	changes.Finished = after.Finished && !before.Finished
+	return changes
+}
+
+// fireWebhooks queues the events following the score of the match, before being the state of the
+// tournament when the score was posted. The deliveries are posted by the webhook worker.
+func fireWebhooks(db *sql.DB, tournamentID string, ref string, before webhookState) {
+	webhooks := selectTournamentWebhooks(db, tournamentID)
+	if len(webhooks) == 0 {
+		return
+	}
+	now := time.Now()
+	tournament := selectTournament(db, tournamentID)
+	matches := make(map[string]displayMatch)
+	for _, match := range loadDisplayMatches(db, tournament, tournament.Locale) {
+		matches[match.Ref] = match
+	}
+	payloads := []webhookPayload{{Event: webhookScoreSaved, Data: webhookMatchOf(matches[ref], true)}}
+	changes := webhookChangesBetween(before, loadWebhookState(db, tournamentID))
+	for _, pool := range selectTournamentPools(db, tournamentID) {
+		for _, poolIndex := range changes.CompletedPools {
+			if pool.Index == poolIndex {
+				payloads = append(payloads, webhookPayload{Event: webhookPoolCompleted, Data: webhookPoolOf(loadPoolRanking(db, tournamentID, pool))})
+			}
+		}
+	}
+	for _, key := range changes.ResolvedMatches {
+		payloads = append(payloads, webhookPayload{Event: webhookTeamsResolved, Data: webhookMatchOf(matches[rankingMatchRef(key)], false)})
+	}
+	if changes.Finished {
+		payloads = append(payloads, webhookPayload{Event: webhookTournamentFinished, Data: webhookFinalRankingOf(loadFinalRanking(db, tournamentID))})
+	}
+	for _, payload := range payloads {
+		payload.TournamentID = tournamentID
+		payload.OccurredAt = now.UTC().Truncate(time.Second)
+		body, err := json.Marshal(payload)
+		if err != nil {
+			panic(err)
+		}
+		for _, webhook := range webhooks {
+			if webhook.Subscribed(payload.Event) {
+				insertWebhookDelivery(db, webhook.ID, payload.Event, string(body), now)
+			}
+		}
+	}
+}
+
+func webhookMatchOf(match displayMatch, withScore bool) webhookMatch {
+	m := webhookMatch{
+		Ref:         match.Ref,
+		Label:       match.Label,
+		ScheduledAt: formatScheduledAt(match.Start),
+		Pitch:       match.PitchName,
+		HomeTeam:    match.HomeTeamName,
+		VisitorTeam: match.VisitorTeamName,
+	}
+	if withScore && match.Played {
+		m.HomeTeamGoals = &match.HomeTeamGoals.Int64
+		m.VisitorTeamGoals = &match.VisitorTeamGoals.Int64
+		m.Score = match.Score
+	}
+	return m
+}
+
+func webhookPoolOf(ranking rankingViewModel) webhookPool {
+	pool := webhookPool{PoolIndex: ranking.PoolIndex, Pool: ranking.PoolName, Ranking: make([]webhookRankedTeam, 0)}
+	for _, team := range ranking.TeamRankings {
+		points := team.Points
+		pool.Ranking = append(pool.Ranking, webhookRankedTeam{Rank: team.Rank, Team: team.Name, Points: &points})
+	}
+	return pool
+}
+
+func webhookFinalRankingOf(ranking []tournamentFinalRanking) webhookFinalRanking {
+	final := webhookFinalRanking{Ranking: make([]webhookRankedTeam, 0)}
+	for _, row := range ranking {
+		final.Ranking = append(final.Ranking, webhookRankedTeam{Rank: row.Rank, Shared: row.Shared, Team: row.TeamName.String})
+	}
+	return final
+}
+
+// signWebhookPayload is the HMAC-SHA256 of the body with the secret of the webhook, sent in the
+// X-Tournament-Signature header so that receivers can check where the event comes from.
+func signWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newWebhookSecret() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// webhookBackoff is the delay before the next attempt, doubling from 30 seconds up to one hour.
+func webhookBackoff(attempts int) time.Duration {
+	delay := 30 * time.Second
+	for i := 1; i < attempts && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	if delay > time.Hour {
+		delay = time.Hour
+	}
+	return delay
+}
+
+// deliverWebhook posts the delivery and returns the HTTP status code, any status but 2xx being an error.
+func deliverWebhook(client *http.Client, delivery webhookDelivery) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, delivery.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "VAFF-Tournament-Webhook")
+	req.Header.Set("X-Tournament-Event", delivery.Event)
+	req.Header.Set("X-Tournament-Delivery", strconv.Itoa(delivery.ID))
+	req.Header.Set("X-Tournament-Signature", signWebhookPayload(delivery.Secret, []byte(delivery.Payload)))
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// processWebhookDeliveries posts the deliveries due at now, scheduling failed ones again with a backoff.
+func processWebhookDeliveries(db *sql.DB, client *http.Client, now time.Time) {
+	for _, delivery := range selectDueWebhookDeliveries(db, now) {
+		statusCode, err := deliverWebhook(client, delivery)
+		delivery.Attempts++
+		delivery.LastStatusCode = sql.NullInt64{Int64: int64(statusCode), Valid: statusCode != 0}
+		delivery.LastError = ""
+		switch {
+		case err == nil:
+			delivery.Status = deliveryDelivered
+		case delivery.Attempts >= webhookMaxAttempts:
+			delivery.Status = deliveryFailed
+			delivery.LastError = err.Error()
+		default:
+			delivery.LastError = err.Error()
+			delivery.NextAttemptAt = now.Add(webhookBackoff(delivery.Attempts))
+		}
+		updateWebhookDelivery(db, delivery)
+	}
+}
+
+// runWebhookWorker posts the due deliveries every interval. The queue lives in the database, so that
+// deliveries survive a restart of the server.
+func runWebhookWorker(db *sql.DB, client *http.Client, interval time.Duration) {
+	for range time.Tick(interval) {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("webhook worker: %v", r)
+				}
+			}()
+			processWebhookDeliveries(db, client, time.Now())
+		}()
+	}
+}
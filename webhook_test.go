@@ -0,0 +1,77 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	// printf '{}' | openssl dgst -sha256 -hmac secret
+	expected := "sha256=77325902caca812dc259733aacd046b73817372c777b8d95b402647474516e13"
+	if signature := signWebhookPayload("secret", []byte("{}")); signature != expected {
+		t.Errorf("Expected %s, got %s.", expected, signature)
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	expected := map[int]time.Duration{1: 30 * time.Second, 2: time.Minute, 4: 4 * time.Minute, 10: time.Hour}
+	for attempts, delay := range expected {
+		if backoff := webhookBackoff(attempts); backoff != delay {
+			t.Errorf("Expected %v after %d attempt(s), got %v.", delay, attempts, backoff)
+		}
+	}
+}
+
+func TestWebhookChangesBetween(t *testing.T) {
+	before := webhookStateOf(
+		[]poolMatch{{PoolIndex: 1, HomeTeamGoals: nullInt(1)}, {PoolIndex: 1}, {PoolIndex: 2}},
+		[]rankingMatch{{Key: "F", HomeTeamID: nullInt(1)}},
+	)
+	after := webhookStateOf(
+		[]poolMatch{{PoolIndex: 1, HomeTeamGoals: nullInt(1)}, {PoolIndex: 1, HomeTeamGoals: nullInt(0)}, {PoolIndex: 2}},
+		[]rankingMatch{{Key: "F", HomeTeamID: nullInt(1), VisitorTeamID: nullInt(4)}},
+	)
+	changes := webhookChangesBetween(before, after)
+	if len(changes.CompletedPools) != 1 || changes.CompletedPools[0] != 1 {
+		t.Errorf("Expected pool 1 to be completed, got %v.", changes.CompletedPools)
+	}
+	if len(changes.ResolvedMatches) != 1 || changes.ResolvedMatches[0] != "F" {
+		t.Errorf("Expected the teams of F to be resolved, got %v.", changes.ResolvedMatches)
+	}
+	if changes.Finished {
+		t.Errorf("Expected the tournament not to be finished.")
+	}
+	if changes := webhookChangesBetween(after, after); len(changes.CompletedPools)+len(changes.ResolvedMatches) > 0 {
+		t.Errorf("Expected no change, got %v.", changes)
+	}
+}
+
+func TestDeliverWebhook(t *testing.T) {
+	var signature, event string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature, event = r.Header.Get("X-Tournament-Signature"), r.Header.Get("X-Tournament-Event")
+		body, _ = ioutil.ReadAll(r.Body)
+		if event == webhookTournamentFinished {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	delivery := webhookDelivery{ID: 1, URL: server.URL, Secret: "secret", Event: webhookScoreSaved, Payload: `{"event":"match.score_saved"}`}
+	if status, err := deliverWebhook(server.Client(), delivery); err != nil || status != http.StatusOK {
+		t.Errorf("Expected the delivery to succeed, got %d %v.", status, err)
+	}
+	if string(body) != delivery.Payload || event != webhookScoreSaved {
+		t.Errorf("Expected the payload and its event, got %s %s.", event, body)
+	}
+	if signature != signWebhookPayload("secret", body) {
+		t.Errorf("Expected the body to be signed with the secret, got %s.", signature)
+	}
+	delivery.Event = webhookTournamentFinished
+	if status, err := deliverWebhook(server.Client(), delivery); err == nil || status != http.StatusServiceUnavailable {
+		t.Errorf("Expected an error on a 503 response, got %d %v.", status, err)
+	}
+}